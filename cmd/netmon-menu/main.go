@@ -62,34 +62,62 @@ func updateTitle(database *db.DB) {
 	startTime := db.GetStartOfDay()
 	endTime := time.Now().Unix()
 
-	logs, err := database.GetLogsInRange(startTime, endTime)
+	groups, err := stats.Run(database, stats.Query{Start: startTime, End: endTime})
 	if err != nil {
 		systray.SetTitle("NetMon: Error")
 		systray.SetTooltip(fmt.Sprintf("Error: %v", err))
 		return
 	}
 
-	if len(logs) == 0 {
+	// Flag stale or failing collection so smaller numbers aren't mistaken for less usage.
+	// A collector that stopped before writing anything today leaves no data at all.
+	warning := healthWarning(database)
+
+	if len(groups) == 0 {
+		if warning != "" {
+			systray.SetTitle("⚠️ 0B")
+			systray.SetTooltip(fmt.Sprintf("No data available for today\n\n⚠️ %s", warning))
+			return
+		}
 		systray.SetTitle("NetMon: 0 B")
 		systray.SetTooltip("No data available for today")
 		return
 	}
 
-	summary := stats.ComputeSummary(logs)
+	summary := stats.SummaryOf(groups[0])
 	totalBytes := summary.TotalBytesIn + summary.TotalBytesOut
 
 	// Format for menu bar (keep it short)
 	formatted := formatBytesShort(totalBytes)
-	systray.SetTitle(fmt.Sprintf("🌐 %s", formatted))
-	
+
 	// Detailed tooltip
 	tooltip := fmt.Sprintf("Today's Network Usage\n\nDownloaded: %s\nUploaded: %s\nTotal: %s",
 		stats.FormatBytes(summary.TotalBytesIn),
 		stats.FormatBytes(summary.TotalBytesOut),
 		stats.FormatBytes(totalBytes))
+
+	if warning != "" {
+		systray.SetTitle(fmt.Sprintf("⚠️ %s", formatted))
+		systray.SetTooltip(fmt.Sprintf("%s\n\n⚠️ %s", tooltip, warning))
+		return
+	}
+
+	systray.SetTitle(fmt.Sprintf("🌐 %s", formatted))
 	systray.SetTooltip(tooltip)
 }
 
+// healthWarning returns a warning if the collector is stale or failing, or "" if it is healthy.
+func healthWarning(database *db.DB) string {
+	health, ok, err := database.GetHealth()
+	if err != nil {
+		return fmt.Sprintf("Could not read collector health: %v", err)
+	}
+	if !ok {
+		return "netmon-service has never run"
+	}
+	return health.Warning(time.Now())
+}
+
 func formatBytesShort(bytes uint64) string {
 	const (
 		KB = 1024
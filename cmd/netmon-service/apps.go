@@ -0,0 +1,53 @@
+package main
+
+import (
+	"netmon/internal/collector"
+	"netmon/internal/db"
+)
+
+// appRefreshSeconds is how often an unchanged app's last_seen is refreshed.
+const appRefreshSeconds = 60
+
+// appRegistry maps app identities to rows of the apps table. Each identity is
+// written when first seen or when its name changes, and otherwise at most
+// once every appRefreshSeconds. Details that vary between processes of one
+// app are recorded by processRegistry instead.
+type appRegistry struct {
+	database *db.DB
+	entries  map[string]appEntry
+}
+
+type appEntry struct {
+	id       int64
+	identity collector.AppIdentity
+	written  int64
+}
+
+func newAppRegistry(database *db.DB) *appRegistry {
+	return &appRegistry{
+		database: database,
+		entries:  make(map[string]appEntry),
+	}
+}
+
+// lookup returns the apps table ID for identity, upserting it if needed.
+func (r *appRegistry) lookup(identity collector.AppIdentity, now int64) (int64, error) {
+	entry, ok := r.entries[identity.Key]
+	if ok && entry.identity.Name == identity.Name && now-entry.written < appRefreshSeconds {
+		return entry.id, nil
+	}
+
+	id, err := r.database.UpsertApp(db.App{
+		Key:      identity.Key,
+		Name:     identity.Name,
+		ExePath:  identity.ExePath,
+		BundleID: identity.BundleID,
+		LastSeen: now,
+	})
+	if err != nil {
+		return 0, err
+	}
+
+	r.entries[identity.Key] = appEntry{id: id, identity: identity, written: now}
+	return id, nil
+}
@@ -0,0 +1,84 @@
+package main
+
+import (
+	"fmt"
+	"log"
+	"net"
+	"netmon/internal/collector"
+	"netmon/internal/db"
+	"strconv"
+)
+
+// listenerRegistry keeps the listeners inventory up to date. Like the apps
+// table, each listener is written when first seen and then at most once every
+// appRefreshSeconds. Listeners never seen before are logged as events, except
+// while the inventory is first being filled.
+type listenerRegistry struct {
+	database *db.DB
+	apps     *appRegistry
+	written  map[listenerKey]int64
+	initial  bool
+}
+
+type listenerKey struct {
+	app string
+	collector.Listener
+}
+
+func newListenerRegistry(database *db.DB, apps *appRegistry) (*listenerRegistry, error) {
+	existing, err := database.GetListeners(0)
+	if err != nil {
+		return nil, err
+	}
+
+	return &listenerRegistry{
+		database: database,
+		apps:     apps,
+		written:  make(map[listenerKey]int64),
+		initial:  len(existing) == 0,
+	}, nil
+}
+
+// record stores the listeners seen in the current tick.
+func (r *listenerRegistry) record(listeners []collector.ActiveListener, now int64) error {
+	written := make(map[listenerKey]int64, len(listeners))
+
+	for _, listener := range listeners {
+		key := listenerKey{listener.Identity.Key, listener.Listener}
+		if last, ok := r.written[key]; ok && now-last < appRefreshSeconds {
+			written[key] = last
+			continue
+		}
+
+		appID, err := r.apps.lookup(listener.Identity, now)
+		if err != nil {
+			return err
+		}
+
+		created, err := r.database.UpsertListener(db.Listener{
+			AppID:     appID,
+			Transport: listener.Transport,
+			Address:   listener.Address,
+			Port:      listener.Port,
+			PID:       listener.PID,
+			LastSeen:  now,
+		})
+		if err != nil {
+			return err
+		}
+		written[key] = now
+
+		if created && !r.initial {
+			detail := fmt.Sprintf("%s (pid %d) listening on %s %s", listener.Identity.Name, listener.PID,
+				listener.Transport, net.JoinHostPort(listener.Address, strconv.Itoa(int(listener.Port))))
+			log.Printf("New listener: %s", detail)
+			if err := r.database.InsertEvent(db.Event{Timestamp: now, Kind: db.EventListener, Detail: detail}); err != nil {
+				return err
+			}
+		}
+	}
+
+	r.written = written
+	r.initial = false
+	return nil
+}
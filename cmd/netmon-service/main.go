@@ -2,21 +2,46 @@ package main
 
 import (
 	"flag"
+	"fmt"
 	"log"
 	"netmon/internal/collector"
 	"netmon/internal/db"
+	"netmon/internal/protocols"
+	"netmon/internal/rules"
+	"netmon/internal/scope"
 	"os"
 	"os/signal"
 	"path/filepath"
+	"sort"
 	"syscall"
 	"time"
 )
 
+// queueSampleInterval is how often socket queues are sampled with -queue-weighting.
+const queueSampleInterval = 250 * time.Millisecond
+
 func main() {
-	var dbPath string
+	var dbPath, rulesPath, portsPath, localCIDRs string
+	var interval time.Duration
+	var rollupDepth int
+	var containerNetns, allNetns, queueWeighting, debugWeights, shortLived bool
 	flag.StringVar(&dbPath, "db", getDefaultDBPath(), "Path to SQLite database file")
+	flag.StringVar(&rulesPath, "rules", rules.DefaultPath(), "Path to app grouping rules file")
+	flag.StringVar(&portsPath, "ports", protocols.DefaultPath(), "Path to protocol port map file")
+	flag.StringVar(&localCIDRs, "local-cidrs", "", "Comma-separated networks counted as LAN (default: subnets of local interfaces)")
+	flag.IntVar(&rollupDepth, "rollup-depth", 0, "Attribute child process traffic to ancestors up to this many levels up (0 = only owner rules)")
+	flag.DurationVar(&interval, "interval", 1*time.Second, "Collection interval (minimum 1s)")
+	flag.BoolVar(&containerNetns, "container-netns", false, "Also read interface counters inside container network namespaces (Linux)")
+	flag.BoolVar(&allNetns, "netns", false, "Also read interface counters inside every network namespace (Linux)")
+	flag.BoolVar(&queueWeighting, "queue-weighting", false, "Also weight apps by data queued on their sockets, sampled between collections (Linux)")
+	flag.BoolVar(&shortLived, "short-lived", true, "Catch processes that exit between collections (Linux, needs root)")
+	flag.BoolVar(&debugWeights, "debug-weights", false, "Log the weight each app was given when splitting traffic")
 	flag.Parse()
 
+	if interval < time.Second {
+		log.Fatalf("Invalid interval %s: must be at least 1s", interval)
+	}
+
 	log.Println("Starting netmon-service...")
 	log.Printf("Database path: %s", dbPath)
 	log.Println("Application tracking: enabled")
@@ -42,28 +67,133 @@ func main() {
 	// Initialize collectors
 	col := collector.NewCollector()
 	appCol := collector.NewAppCollector()
+	apps := newAppRegistry(database)
+	listeners, err := newListenerRegistry(database, apps)
+	if err != nil {
+		log.Fatalf("Failed to load listeners: %v", err)
+	}
+	remotes := newRemoteRegistry(database, apps)
+	processes, err := newProcessRegistry(database, apps)
+	if err != nil {
+		log.Fatalf("Failed to load processes: %v", err)
+	}
+
+	ruleSet, err := rules.Load(rulesPath)
+	if err != nil {
+		log.Fatalf("Failed to load rules: %v", err)
+	}
+	appCol.SetRules(ruleSet)
+	appCol.SetRollupDepth(rollupDepth)
+
+	ports, err := protocols.Load(portsPath)
+	if err != nil {
+		log.Fatalf("Failed to load port map: %v", err)
+	}
+	appCol.SetPorts(ports)
+
+	localNets, err := scope.ParseCIDRs(localCIDRs)
+	if err != nil {
+		log.Fatalf("Invalid -local-cidrs: %v", err)
+	}
+	appCol.SetScopes(scope.New(localNets))
+
+	done := make(chan struct{})
+	defer close(done)
+	if queueWeighting {
+		sampler := collector.NewQueueSampler()
+		appCol.SetQueueSampler(sampler)
+		go sampler.Run(queueSampleInterval, done)
+		log.Println("Socket queue weighting: enabled")
+	}
+
+	if shortLived {
+		tracker, err := collector.NewShortLivedTracker()
+		if err != nil {
+			log.Printf("Short-lived process tracking unavailable: %v", err)
+		} else {
+			defer tracker.Close()
+			appCol.SetShortLivedTracker(tracker)
+			log.Println("Short-lived process tracking: enabled")
+		}
+	}
+
+	var netnsCol *collector.NetnsCollector
+	if containerNetns || allNetns {
+		netnsCol = collector.NewNetnsCollector()
+		netnsCol.SetAllNamespaces(allNetns)
+		if allNetns {
+			log.Println("Network namespace tracking: enabled")
+		} else {
+			log.Println("Container namespace tracking: enabled")
+		}
+	}
 
 	// Setup graceful shutdown
 	stop := make(chan os.Signal, 1)
 	signal.Notify(stop, syscall.SIGINT, syscall.SIGTERM)
 
-	// Create ticker for 1-second intervals
-	ticker := time.NewTicker(1 * time.Second)
+	// Create ticker for the collection interval
+	ticker := time.NewTicker(interval)
 	defer ticker.Stop()
 
-	log.Println("Collection started (1-second intervals)")
+	log.Printf("Collection started (%s intervals)", interval)
+
+	health := db.Health{
+		PID:             os.Getpid(),
+		IntervalSeconds: int64(interval / time.Second),
+	}
 
 	for {
 		select {
 		case <-ticker.C:
-			if err := collectAndStore(col, database); err != nil {
+			// One read of the interface counters feeds both tables, so
+			// interface and app logs share timestamps and totals
+			var tickErr error
+			deltas, err := collectAndStore(col, database)
+			if err != nil {
 				log.Printf("Collection error: %v", err)
+				tickErr = fmt.Errorf("collect interfaces: %w", err)
 			}
 
-			if err := collectAndStoreApps(appCol, database); err != nil {
+			if err := collectAndStoreApps(appCol, deltas, apps, database, debugWeights); err != nil {
 				log.Printf("App collection error: %v", err)
+				if tickErr == nil {
+					tickErr = fmt.Errorf("collect apps: %w", err)
+				}
+			}
+
+			if err := listeners.record(appCol.GetListeners(), time.Now().Unix()); err != nil {
+				log.Printf("Listener inventory error: %v", err)
+				if tickErr == nil {
+					tickErr = fmt.Errorf("record listeners: %w", err)
+				}
+			}
+
+			if err := remotes.record(appCol.GetRemotes(), time.Now().Unix()); err != nil {
+				log.Printf("Remote host tracking error: %v", err)
+				if tickErr == nil {
+					tickErr = fmt.Errorf("record remotes: %w", err)
+				}
+			}
+
+			if err := processes.record(appCol.GetProcesses(), time.Now().Unix()); err != nil {
+				log.Printf("Process tracking error: %v", err)
+				if tickErr == nil {
+					tickErr = fmt.Errorf("record processes: %w", err)
+				}
 			}
 
+			if netnsCol != nil {
+				if err := collectAndStoreNetns(netnsCol, database); err != nil {
+					log.Printf("Namespace collection error: %v", err)
+					if tickErr == nil {
+						tickErr = fmt.Errorf("collect namespaces: %w", err)
+					}
+				}
+			}
+
+			recordHealth(&health, tickErr, database)
+
 		case sig := <-stop:
 			log.Printf("Received signal: %v", sig)
 			log.Println("Shutting down gracefully...")
@@ -73,15 +203,23 @@ func main() {
 }
 
 // collectAndStore collects network stats and stores them in the database.
-func collectAndStore(col *collector.Collector, database *db.DB) error {
+// The deltas are returned even if storing them fails, so they can still be
+// attributed to apps.
+func collectAndStore(col *collector.Collector, database *db.DB) ([]collector.Delta, error) {
 	deltas, err := col.Collect()
 	if err != nil {
-		return err
+		return nil, err
+	}
+
+	if suspend, ok := col.LastSuspend(); ok {
+		if err := recordSuspend(suspend, database); err != nil {
+			return deltas, err
+		}
 	}
 
 	// First collection returns nil deltas
 	if deltas == nil {
-		return nil
+		return nil, nil
 	}
 
 	for _, delta := range deltas {
@@ -90,19 +228,42 @@ func collectAndStore(col *collector.Collector, database *db.DB) error {
 			Interface: delta.Interface,
 			BytesIn:   delta.BytesIn,
 			BytesOut:  delta.BytesOut,
+			Interval:  delta.Interval,
 		}
 
 		if err := database.InsertTrafficLog(log); err != nil {
-			return err
+			return deltas, err
 		}
 	}
 
-	return nil
+	return deltas, nil
 }
 
-// collectAndStoreApps collects per-app network stats and stores them in the database.
-func collectAndStoreApps(appCol *collector.AppCollector, database *db.DB) error {
-	appDeltas, err := appCol.CollectWithWeighting()
+// recordSuspend logs a detected sleep period as suspend and resume events.
+func recordSuspend(suspend collector.Suspend, database *db.DB) error {
+	asleep := time.Duration(suspend.End-suspend.Start) * time.Second
+	log.Printf("System resumed after sleeping for %s", asleep)
+
+	if err := database.InsertEvent(db.Event{
+		Timestamp: suspend.Start,
+		Kind:      db.EventSuspend,
+		Detail:    "system went to sleep",
+	}); err != nil {
+		return err
+	}
+
+	return database.InsertEvent(db.Event{
+		Timestamp: suspend.End,
+		Kind:      db.EventResume,
+		Detail:    fmt.Sprintf("system woke after %s", asleep),
+	})
+}
+
+// collectAndStoreApps attributes interface deltas to apps and stores the
+// per-app stats in the database, logging the weight of each app if
+// debugWeights is set.
+func collectAndStoreApps(appCol *collector.AppCollector, deltas []collector.Delta, apps *appRegistry, database *db.DB, debugWeights bool) error {
+	appDeltas, err := appCol.AttributeWithWeighting(deltas)
 	if err != nil {
 		return err
 	}
@@ -112,12 +273,38 @@ func collectAndStoreApps(appCol *collector.AppCollector, database *db.DB) error
 		return nil
 	}
 
+	if debugWeights {
+		logWeights(appDeltas)
+	}
+
 	for _, delta := range appDeltas {
+		appID, err := apps.lookup(delta.Identity, delta.Timestamp)
+		if err != nil {
+			return err
+		}
+
+		var parentAppID int64
+		if delta.Owner != nil {
+			if parentAppID, err = apps.lookup(*delta.Owner, delta.Timestamp); err != nil {
+				return err
+			}
+		}
+
 		log := db.AppTrafficLog{
-			Timestamp: delta.Timestamp,
-			AppName:   delta.AppName,
-			BytesIn:   delta.BytesIn,
-			BytesOut:  delta.BytesOut,
+			Timestamp:   delta.Timestamp,
+			AppID:       appID,
+			AppName:     delta.AppName,
+			BytesIn:     delta.BytesIn,
+			BytesOut:    delta.BytesOut,
+			Interval:    delta.Interval,
+			ParentAppID: parentAppID,
+			UID:         delta.Identity.UID,
+			Username:    delta.Identity.Username,
+			ContainerID: delta.Identity.ContainerID,
+			SystemdUnit: delta.Identity.SystemdUnit,
+			Protocol:    delta.Protocol,
+			Scope:       delta.Scope,
+			Family:      delta.Family,
 		}
 
 		if err := database.InsertAppTrafficLog(log); err != nil {
@@ -128,6 +315,79 @@ func collectAndStoreApps(appCol *collector.AppCollector, database *db.DB) error
 	return nil
 }
 
+// logWeights logs how traffic was split among apps, heaviest first.
+func logWeights(appDeltas []collector.AppDelta) {
+	sorted := make([]collector.AppDelta, len(appDeltas))
+	copy(sorted, appDeltas)
+	sort.Slice(sorted, func(i, j int) bool {
+		return sorted[i].Weight > sorted[j].Weight
+	})
+
+	for _, delta := range sorted {
+		log.Printf("Weight %5.1f%%  %-25s %-12s %-8s %-5s conns=%d queued=%d closed=%d in=%d out=%d",
+			delta.Weight*100,
+			delta.AppName,
+			delta.Protocol,
+			delta.Scope,
+			delta.Family,
+			delta.Connections,
+			delta.QueuedBytes,
+			delta.ClosedBytes,
+			delta.BytesIn,
+			delta.BytesOut)
+	}
+}
+
+// collectAndStoreNetns collects per-namespace interface stats and stores them in the database.
+func collectAndStoreNetns(netnsCol *collector.NetnsCollector, database *db.DB) error {
+	deltas, err := netnsCol.Collect()
+	if err != nil {
+		return err
+	}
+
+	for _, delta := range deltas {
+		log := db.NetnsTrafficLog{
+			Timestamp:   delta.Timestamp,
+			Netns:       delta.Netns,
+			Name:        delta.Name,
+			ContainerID: delta.ContainerID,
+			Process:     delta.Process,
+			Interface:   delta.Interface,
+			BytesIn:     delta.BytesIn,
+			BytesOut:    delta.BytesOut,
+			Interval:    delta.Interval,
+		}
+
+		if err := database.InsertNetnsTrafficLog(log); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+// recordHealth updates the heartbeat record with the outcome of a tick and stores it.
+func recordHealth(health *db.Health, tickErr error, database *db.DB) {
+	now := time.Now().Unix()
+	health.Heartbeat = now
+
+	if tickErr != nil {
+		health.ConsecutiveFailures++
+		health.LastError = tickErr.Error()
+		health.LastErrorAt = now
+		if health.ConsecutiveFailures == 10 {
+			log.Printf("Collector has failed %d consecutive times", health.ConsecutiveFailures)
+		}
+	} else {
+		health.ConsecutiveFailures = 0
+		health.LastSuccess = now
+	}
+
+	if err := database.UpdateHealth(*health); err != nil {
+		log.Printf("Health update error: %v", err)
+	}
+}
+
 // getDefaultDBPath returns the default database path in user's home directory.
 func getDefaultDBPath() string {
 	home, err := os.UserHomeDir()
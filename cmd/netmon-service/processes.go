@@ -0,0 +1,113 @@
+package main
+
+import (
+	"netmon/internal/collector"
+	"netmon/internal/db"
+)
+
+// processRegistry keeps the processes table up to date. Like the apps table,
+// each process is written when first seen and then at most once every
+// appRefreshSeconds while it uses the network. Once a process is no longer
+// seen, it is checked each tick and marked exited when it is gone.
+type processRegistry struct {
+	database *db.DB
+	apps     *appRegistry
+	running  map[collector.ProcessKey]processEntry
+}
+
+type processEntry struct {
+	id      int64
+	written int64
+}
+
+// newProcessRegistry resumes tracking processes left running by a previous
+// run, marking those that exited meanwhile as exited when last seen.
+func newProcessRegistry(database *db.DB, apps *appRegistry) (*processRegistry, error) {
+	existing, err := database.GetRunningProcesses()
+	if err != nil {
+		return nil, err
+	}
+
+	r := &processRegistry{
+		database: database,
+		apps:     apps,
+		running:  make(map[collector.ProcessKey]processEntry),
+	}
+
+	for _, p := range existing {
+		key := collector.ProcessKey{PID: p.PID, StartTime: p.StartTime}
+		if !collector.ProcessAlive(key) {
+			if err := database.MarkProcessExited(p.ID, p.LastSeen); err != nil {
+				return nil, err
+			}
+			continue
+		}
+		r.running[key] = processEntry{id: p.ID, written: p.LastSeen}
+	}
+
+	return r, nil
+}
+
+// record stores the processes seen in the current tick and the exit of those
+// seen before that are gone.
+func (r *processRegistry) record(processes []collector.SeenProcess, now int64) error {
+	seen := make(map[collector.ProcessKey]bool, len(processes))
+
+	for _, p := range processes {
+		if !p.Exited {
+			seen[p.ProcessKey] = true
+			if entry, ok := r.running[p.ProcessKey]; ok && now-entry.written < appRefreshSeconds {
+				continue
+			}
+		}
+
+		appID, err := r.apps.lookup(p.Identity, now)
+		if err != nil {
+			return err
+		}
+
+		// The command line is left out: it may hold tokens or passwords
+		row := db.Process{
+			AppID:       appID,
+			PID:         p.PID,
+			StartTime:   p.StartTime,
+			Name:        p.Name,
+			UID:         p.Identity.UID,
+			Username:    p.Identity.Username,
+			PPID:        p.Identity.PPID,
+			Cgroup:      p.Identity.Cgroup,
+			SystemdUnit: p.Identity.SystemdUnit,
+			ContainerID: p.Identity.ContainerID,
+			LastSeen:    now,
+		}
+		if p.Exited {
+			// Short-lived processes exited during the last interval
+			row.ExitedAt = now
+		}
+
+		id, err := r.database.UpsertProcess(row)
+		if err != nil {
+			return err
+		}
+
+		if p.Exited {
+			delete(r.running, p.ProcessKey)
+		} else {
+			r.running[p.ProcessKey] = processEntry{id: id, written: now}
+		}
+	}
+
+	// Processes may go without sockets for a while, so only their exit
+	// ends tracking
+	for key, entry := range r.running {
+		if seen[key] || collector.ProcessAlive(key) {
+			continue
+		}
+		if err := r.database.MarkProcessExited(entry.id, now); err != nil {
+			return err
+		}
+		delete(r.running, key)
+	}
+
+	return nil
+}
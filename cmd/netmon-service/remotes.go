@@ -0,0 +1,59 @@
+package main
+
+import (
+	"netmon/internal/collector"
+	"netmon/internal/db"
+)
+
+// remoteRegistry records the hosts apps connect to. Like listeners, each
+// remote is written when first seen and then at most once every
+// appRefreshSeconds.
+type remoteRegistry struct {
+	database *db.DB
+	apps     *appRegistry
+	written  map[remoteKey]int64
+}
+
+type remoteKey struct {
+	app string
+	collector.Remote
+}
+
+func newRemoteRegistry(database *db.DB, apps *appRegistry) *remoteRegistry {
+	return &remoteRegistry{
+		database: database,
+		apps:     apps,
+		written:  make(map[remoteKey]int64),
+	}
+}
+
+// record stores the remotes seen in the current tick.
+func (r *remoteRegistry) record(remotes []collector.ActiveRemote, now int64) error {
+	written := make(map[remoteKey]int64, len(remotes))
+
+	for _, remote := range remotes {
+		key := remoteKey{remote.Identity.Key, remote.Remote}
+		if last, ok := r.written[key]; ok && now-last < appRefreshSeconds {
+			written[key] = last
+			continue
+		}
+
+		appID, err := r.apps.lookup(remote.Identity, now)
+		if err != nil {
+			return err
+		}
+
+		if err := r.database.UpsertRemote(db.Remote{
+			AppID:     appID,
+			Address:   remote.Address,
+			Interface: remote.Interface,
+			LastSeen:  now,
+		}); err != nil {
+			return err
+		}
+		written[key] = now
+	}
+
+	r.written = written
+	return nil
+}
@@ -0,0 +1,343 @@
+package main
+
+import (
+	"fmt"
+	"netmon/internal/db"
+	"netmon/internal/rules"
+	"netmon/internal/stats"
+	"os"
+	"sort"
+	"strings"
+	"time"
+)
+
+// appRemoteLimit is how many remote hosts the app drill-down lists.
+const appRemoteLimit = 15
+
+// timelineBarWidth is the width of the bar of the busiest timeline row.
+const timelineBarWidth = 30
+
+// handleApp shows the traffic of one app in detail: totals, peak, active
+// time, a timeline, the interfaces and hosts it used, and how the range
+// compares with the app's recent average.
+func handleApp(database *db.DB, ruleSet *rules.Set, args []string) {
+	if len(args) < 1 {
+		fmt.Fprintln(os.Stderr, "Usage: netmon app <name> [range]")
+		os.Exit(1)
+	}
+
+	rangeName := "today"
+	if len(args) > 1 {
+		rangeName = args[1]
+	}
+	startTime, label, ok := resolveRange(rangeName)
+	if !ok {
+		fmt.Fprintf(os.Stderr, "Unknown range: %s\n", rangeName)
+		printUsage()
+		os.Exit(1)
+	}
+	endTime := time.Now().Unix()
+
+	app, err := findApp(database, args[0])
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "Error: %v\n", err)
+		os.Exit(1)
+	}
+
+	fmt.Printf("%s (%s)\n", app.Name, label)
+	fmt.Printf("  Key:        %s\n", app.Key)
+	fmt.Printf("  Category:   %s\n", stats.AppCategory(app, ruleSet))
+	if usernames, err := database.GetAppUsernames(app.ID); err == nil && len(usernames) > 0 {
+		fmt.Printf("  Users:      %s\n", strings.Join(usernames, ", "))
+	}
+	fmt.Printf("  First seen: %s\n", time.Unix(app.FirstSeen, 0).Format("2006-01-02 15:04:05"))
+	fmt.Printf("  Last seen:  %s\n", time.Unix(app.LastSeen, 0).Format("2006-01-02 15:04:05"))
+	fmt.Println()
+
+	groups, err := stats.Run(database, stats.Query{
+		Start:   startTime,
+		End:     endTime,
+		App:     app.Key,
+		Metrics: []stats.Metric{stats.Sum, stats.PeakRate, stats.AvgRate, stats.ActiveTime},
+	})
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "Error fetching logs: %v\n", err)
+		os.Exit(1)
+	}
+	if len(groups) == 0 {
+		fmt.Printf("No data available for %s\n", label)
+		return
+	}
+	g := groups[0]
+
+	fmt.Printf("  Downloaded: %s\n", stats.FormatBytes(g.In.Bytes))
+	fmt.Printf("  Uploaded:   %s\n", stats.FormatBytes(g.Out.Bytes))
+	fmt.Printf("  Total:      %s\n", stats.FormatBytes(g.Total()))
+	fmt.Printf("  Peak Down:  %s%s\n", stats.FormatBytesPerSec(g.In.PeakRate), peakTime(g.In))
+	fmt.Printf("  Peak Up:    %s%s\n", stats.FormatBytesPerSec(g.Out.PeakRate), peakTime(g.Out))
+	fmt.Printf("  Avg Down:   %s\n", stats.FormatBytesPerSec(g.In.AvgRate))
+	fmt.Printf("  Avg Up:     %s\n", stats.FormatBytesPerSec(g.Out.AvgRate))
+	fmt.Printf("  Active:     %s (%.1f%% of the range)\n", time.Duration(g.ActiveSeconds)*time.Second,
+		float64(g.ActiveSeconds)*100/float64(endTime-startTime+1))
+	printAppBaseline(database, app, rangeName, startTime, endTime, g.Total())
+	fmt.Println()
+	printCoverage(database, startTime, endTime)
+	fmt.Println()
+
+	printAppTimeline(database, app, rangeName, startTime, endTime)
+	fmt.Println()
+	printAppRemotes(database, app, startTime, endTime)
+}
+
+// peakTime formats when a peak rate was reached, if it was.
+func peakTime(f stats.Flow) string {
+	if f.PeakRate == 0 {
+		return ""
+	}
+	return " at " + time.Unix(f.PeakAt, 0).Format("2006-01-02 15:04:05")
+}
+
+// timeWindow is a span of time, both ends inclusive.
+type timeWindow struct {
+	start, end int64
+}
+
+// previousWindows returns the same stretch of the periods before a range:
+// the same time of day on the previous days for today, and the same part of
+// the previous weeks or months. Each window ends before the period after it
+// starts, so a month shorter than the stretch (February, late in March)
+// never overlaps the next one. All time has no periods before it.
+func previousWindows(rangeName string, startTime, endTime int64) ([]timeWindow, string) {
+	var count, months, days int
+	var unit string
+	switch rangeName {
+	case "today":
+		count, days, unit = 7, 1, "days"
+	case "week":
+		count, days, unit = 4, 7, "weeks"
+	case "month":
+		count, months, unit = 3, 1, "months"
+	default:
+		return nil, ""
+	}
+
+	from := time.Unix(startTime, 0)
+	windows := make([]timeWindow, 0, count)
+	for k := 1; k <= count; k++ {
+		start := from.AddDate(0, -k*months, -k*days).Unix()
+		next := from.AddDate(0, -(k-1)*months, -(k-1)*days).Unix()
+		windows = append(windows, timeWindow{start, min(start+endTime-startTime, next-1)})
+	}
+	return windows, fmt.Sprintf("the previous %d %s", count, unit)
+}
+
+// printAppBaseline compares an app's traffic in a range with its average
+// over the same stretch of the preceding periods it was around for.
+func printAppBaseline(database *db.DB, app db.App, rangeName string, startTime, endTime int64, total uint64) {
+	windows, label := previousWindows(rangeName, startTime, endTime)
+	if windows == nil {
+		return
+	}
+
+	var sum uint64
+	var counted int
+	for _, w := range windows {
+		if w.start < app.FirstSeen {
+			continue // the app wasn't known yet, so zero would understate its average
+		}
+		groups, err := stats.Run(database, stats.Query{Start: w.start, End: w.end, App: app.Key})
+		if err != nil {
+			fmt.Fprintf(os.Stderr, "Error fetching logs: %v\n", err)
+			os.Exit(1)
+		}
+		if len(groups) > 0 {
+			sum += groups[0].Total()
+		}
+		counted++
+	}
+
+	if counted == 0 {
+		fmt.Printf("  Usual:      not enough history to compare with %s\n", label)
+		return
+	}
+	average := sum / uint64(counted)
+	fmt.Printf("  Usual:      %s by this point on average over %s (%s)\n",
+		stats.FormatBytes(average), label, formatChange(total, average))
+}
+
+// formatChange formats how current differs from previous as a percentage.
+func formatChange(current, previous uint64) string {
+	if previous == 0 {
+		if current == 0 {
+			return "no change"
+		}
+		return "new"
+	}
+	percent := (float64(current) - float64(previous)) * 100 / float64(previous)
+	if percent > -0.5 && percent < 0.5 {
+		return "0%" // rather than "-0%"
+	}
+	return fmt.Sprintf("%+.0f%%", percent)
+}
+
+// printAppTimeline shows the app's traffic per hour for today and per day
+// for longer ranges.
+func printAppTimeline(database *db.DB, app db.App, rangeName string, startTime, endTime int64) {
+	dimension := stats.ByDay
+	if rangeName == "today" {
+		dimension = stats.ByHour
+	}
+
+	groups, err := stats.Run(database, stats.Query{
+		Start:   startTime,
+		End:     endTime,
+		App:     app.Key,
+		GroupBy: []stats.Dimension{dimension},
+	})
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "Error fetching logs: %v\n", err)
+		os.Exit(1)
+	}
+
+	var busiest uint64
+	for _, g := range groups {
+		if g.Total() > busiest {
+			busiest = g.Total()
+		}
+	}
+
+	fmt.Println("Timeline:")
+	fmt.Printf("%-16s %-15s %-15s %-15s\n", dimensionTitle(dimension), "Downloaded", "Uploaded", "Total")
+	fmt.Println("----------------------------------------------------------------")
+	for _, g := range groups {
+		bar := 0
+		if busiest > 0 {
+			bar = int(g.Total() * timelineBarWidth / busiest)
+		}
+		fmt.Printf("%-16s %-15s %-15s %-15s %s\n",
+			g.Labels[0],
+			stats.FormatBytes(g.In.Bytes),
+			stats.FormatBytes(g.Out.Bytes),
+			stats.FormatBytes(g.Total()),
+			strings.Repeat("#", bar))
+	}
+}
+
+// printAppRemotes lists the interfaces and remote hosts the app's
+// connections used in the range.
+func printAppRemotes(database *db.DB, app db.App, startTime, endTime int64) {
+	remotes, err := database.GetAppRemotes(app.Key, startTime, endTime)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "Error fetching remote hosts: %v\n", err)
+		os.Exit(1)
+	}
+	if len(remotes) == 0 {
+		fmt.Println("No remote hosts recorded for this range")
+		return
+	}
+
+	hosts := make(map[string]int)
+	var interfaces []string
+	for _, r := range remotes {
+		name := r.Interface
+		if name == "" {
+			name = "(unknown)"
+		}
+		if hosts[name] == 0 {
+			interfaces = append(interfaces, name)
+		}
+		hosts[name]++
+	}
+	sort.Strings(interfaces)
+
+	// App traffic is split among apps from the interface totals, so bytes
+	// per interface and host are not known; connections show which were used
+	fmt.Println("Interfaces:")
+	for _, name := range interfaces {
+		fmt.Printf("  %-15s remote hosts: %d\n", name, hosts[name])
+	}
+	fmt.Println()
+
+	fmt.Println("Remote hosts:")
+	fmt.Printf("%-40s %-15s %-20s %-20s\n", "Address", "Interface", "First seen", "Last seen")
+	fmt.Println("------------------------------------------------------------------------------------------------")
+	for i, r := range remotes {
+		if i == appRemoteLimit {
+			fmt.Printf("... and %d more\n", len(remotes)-appRemoteLimit)
+			break
+		}
+		fmt.Printf("%-40s %-15s %-20s %-20s\n",
+			r.Address,
+			r.Interface,
+			time.Unix(r.FirstSeen, 0).Format("2006-01-02 15:04:05"),
+			time.Unix(r.LastSeen, 0).Format("2006-01-02 15:04:05"))
+	}
+}
+
+// findApp finds an app by key or name. Names are matched case-insensitively,
+// exactly if possible and otherwise by substring, then by the letters of the
+// name in order (e.g. "ffx" for Firefox). Of several apps with the matching
+// name, the most recently seen one is used; several names are ambiguous.
+func findApp(database *db.DB, name string) (db.App, error) {
+	apps, err := database.GetApps()
+	if err != nil {
+		return db.App{}, err
+	}
+
+	for _, app := range apps {
+		if app.Key == name {
+			return app, nil
+		}
+	}
+
+	query := strings.ToLower(name)
+	matchers := []func(string) bool{
+		func(s string) bool { return s == query },
+		func(s string) bool { return strings.Contains(s, query) },
+		func(s string) bool { return isSubsequence(query, s) },
+	}
+	for _, matches := range matchers {
+		best := make(map[string]db.App) // most recently seen app per lowercased name
+		for _, app := range apps {
+			lower := strings.ToLower(app.Name)
+			if !matches(lower) {
+				continue
+			}
+			if seen, ok := best[lower]; !ok || app.LastSeen > seen.LastSeen {
+				best[lower] = app
+			}
+		}
+
+		switch len(best) {
+		case 0:
+			continue
+		case 1:
+			for _, app := range best {
+				return app, nil
+			}
+		default:
+			names := make([]string, 0, len(best))
+			for _, app := range best {
+				names = append(names, app.Name)
+			}
+			sort.Strings(names)
+			return db.App{}, fmt.Errorf("%q matches several apps: %s", name, strings.Join(names, ", "))
+		}
+	}
+
+	return db.App{}, fmt.Errorf("no app matching %q", name)
+}
+
+// isSubsequence reports whether the characters of sub appear in s in order.
+func isSubsequence(sub, s string) bool {
+	rest := []rune(sub)
+	for _, r := range s {
+		if len(rest) == 0 {
+			break
+		}
+		if r == rest[0] {
+			rest = rest[1:]
+		}
+	}
+	return len(rest) == 0
+}
@@ -0,0 +1,113 @@
+package main
+
+import (
+	"fmt"
+	"netmon/internal/db"
+	"netmon/internal/rules"
+	"netmon/internal/stats"
+	"os"
+	"sort"
+	"strings"
+	"time"
+)
+
+// loadRules loads the app grouping rules, exiting on an invalid rules file.
+func loadRules(path string) *rules.Set {
+	ruleSet, err := rules.Load(path)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "Error loading rules: %v\n", err)
+		os.Exit(1)
+	}
+	return ruleSet
+}
+
+// appFilter narrows the apps view to part of the traffic. Empty fields match everything.
+type appFilter struct {
+	user     string // username or UID
+	protocol string
+}
+
+// label describes the filter for view headings, e.g. "user alice, HTTPS".
+func (f appFilter) label() string {
+	var parts []string
+	if f.user != "" {
+		parts = append(parts, "user "+f.user)
+	}
+	if f.protocol != "" {
+		parts = append(parts, f.protocol)
+	}
+	return strings.Join(parts, ", ")
+}
+
+// appSummaries computes per-app totals for a range with the grouping rules applied.
+func appSummaries(database *db.DB, ruleSet *rules.Set, startTime, endTime int64, filter appFilter) ([]stats.AppSummary, error) {
+	groups, err := stats.Run(database, stats.Query{
+		Start:    startTime,
+		End:      endTime,
+		User:     filter.user,
+		Protocol: filter.protocol,
+		GroupBy:  []stats.Dimension{stats.ByApp},
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	apps, err := database.GetApps()
+	if err != nil {
+		return nil, err
+	}
+
+	return stats.ApplyRules(stats.AppSummaries(groups), apps, ruleSet), nil
+}
+
+func showStatsCategories(database *db.DB, ruleSet *rules.Set) {
+	startTime := db.GetStartOfDay()
+	endTime := time.Now().Unix()
+
+	summaries, err := appSummaries(database, ruleSet, startTime, endTime, appFilter{})
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "Error fetching app logs: %v\n", err)
+		os.Exit(1)
+	}
+
+	if len(summaries) == 0 {
+		fmt.Println("No application data available for today")
+		fmt.Println("Make sure netmon-service is running")
+		return
+	}
+
+	categories := stats.ComputeByCategory(summaries)
+
+	// Sort by total traffic (descending)
+	sort.Slice(categories, func(i, j int) bool {
+		return categories[i].TotalBytesIn+categories[i].TotalBytesOut > categories[j].TotalBytesIn+categories[j].TotalBytesOut
+	})
+
+	var totalIn, totalOut uint64
+	for _, category := range categories {
+		totalIn += category.TotalBytesIn
+		totalOut += category.TotalBytesOut
+	}
+
+	fmt.Println("Stats by category (today)")
+	fmt.Println()
+	fmt.Println("Overall Totals:")
+	fmt.Printf("  Downloaded: %s\n", stats.FormatBytes(totalIn))
+	fmt.Printf("  Uploaded:   %s\n", stats.FormatBytes(totalOut))
+	fmt.Printf("  Total:      %s\n", stats.FormatBytes(totalIn+totalOut))
+	fmt.Println()
+	printCoverage(database, startTime, endTime)
+	fmt.Println()
+	fmt.Printf("%-20s %-6s %-15s %-15s %-15s\n", "Category", "Apps", "Downloaded", "Uploaded", "Total")
+	fmt.Println("--------------------------------------------------------------------------")
+
+	for _, category := range categories {
+		total := category.TotalBytesIn + category.TotalBytesOut
+		fmt.Printf("%-20s %-6d %-15s %-15s %-15s\n",
+			category.Category,
+			category.Apps,
+			stats.FormatBytes(category.TotalBytesIn),
+			stats.FormatBytes(category.TotalBytesOut),
+			stats.FormatBytes(total))
+	}
+}
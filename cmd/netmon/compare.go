@@ -0,0 +1,180 @@
+package main
+
+import (
+	"encoding/json"
+	"flag"
+	"fmt"
+	"netmon/internal/db"
+	"netmon/internal/stats"
+	"os"
+	"strings"
+	"time"
+)
+
+// compareAppLimit is how many apps the comparison table lists.
+const compareAppLimit = 20
+
+// handleCompare compares the traffic of this day, week or month so far with
+// the same stretch of the one before, or of two given ranges.
+func handleCompare(database *db.DB, args []string) {
+	fs := flag.NewFlagSet("netmon compare", flag.ExitOnError)
+	period := fs.String("period", "week", "Compare this day, week or month so far with the same stretch of the previous one")
+	previousFlag := fs.String("previous", "", "Earlier range to compare, e.g. 2026-10-01..2026-10-07 (with --current)")
+	currentFlag := fs.String("current", "", "Later range to compare, e.g. 2026-10-08..2026-10-14 (with --previous)")
+	asJSON := fs.Bool("json", false, "Print the comparison as JSON")
+	fs.Parse(args)
+
+	var previous, current stats.TimeRange
+	var previousLabel, currentLabel string
+	switch {
+	case *previousFlag != "" || *currentFlag != "":
+		var err error
+		if previous, err = parseDateRange(*previousFlag); err != nil {
+			fmt.Fprintf(os.Stderr, "Error: --previous: %v\n", err)
+			os.Exit(1)
+		}
+		if current, err = parseDateRange(*currentFlag); err != nil {
+			fmt.Fprintf(os.Stderr, "Error: --current: %v\n", err)
+			os.Exit(1)
+		}
+		previousLabel, currentLabel = *previousFlag, *currentFlag
+	default:
+		var ok bool
+		if previous, current, previousLabel, currentLabel, ok = periodRanges(*period); !ok {
+			fmt.Fprintf(os.Stderr, "Unknown period: %s (want day, week or month)\n", *period)
+			os.Exit(1)
+		}
+	}
+
+	comparison, err := stats.Compare(database, previous, current)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "Error comparing ranges: %v\n", err)
+		os.Exit(1)
+	}
+
+	if *asJSON {
+		out, err := json.MarshalIndent(comparison, "", "  ")
+		if err != nil {
+			fmt.Fprintf(os.Stderr, "Error encoding comparison: %v\n", err)
+			os.Exit(1)
+		}
+		fmt.Println(string(out))
+		return
+	}
+
+	fmt.Printf("Comparing %s (%s)\n", currentLabel, formatTimeRange(current))
+	fmt.Printf("     with %s (%s)\n", previousLabel, formatTimeRange(previous))
+	fmt.Println()
+
+	if comparison.Total.Previous == 0 && comparison.Total.Current == 0 {
+		fmt.Println("No data available for either range")
+		return
+	}
+
+	t := comparison.Total
+	fmt.Printf("Total: %s → %s (%s, %s)\n",
+		stats.FormatBytes(t.Previous), stats.FormatBytes(t.Current), formatDelta(t.Delta), formatChange(t.Current, t.Previous))
+	fmt.Println()
+
+	fmt.Println("By interface:")
+	printChanges("Interface", comparison.Interfaces, len(comparison.Interfaces))
+	fmt.Println()
+	fmt.Println("By app:")
+	printChanges("Application", comparison.Apps, compareAppLimit)
+
+	if len(comparison.NewApps) > 0 {
+		fmt.Println()
+		fmt.Println("New apps:")
+		for _, app := range comparison.NewApps {
+			fmt.Printf("  %-25s %s\n", truncate(app.Label, 25), stats.FormatBytes(app.Current))
+		}
+	}
+	if len(comparison.GoneApps) > 0 {
+		fmt.Println()
+		fmt.Println("Apps no longer seen:")
+		for _, app := range comparison.GoneApps {
+			fmt.Printf("  %-25s %s before\n", truncate(app.Label, 25), stats.FormatBytes(app.Previous))
+		}
+	}
+}
+
+// printChanges prints up to limit changes as a table.
+func printChanges(title string, changes []stats.Change, limit int) {
+	fmt.Printf("%-25s %-15s %-15s %-15s %-10s\n", title, "Previous", "Current", "Change", "%")
+	fmt.Println("---------------------------------------------------------------------------------")
+	for i, c := range changes {
+		if i == limit {
+			fmt.Printf("... and %d more\n", len(changes)-limit)
+			break
+		}
+		fmt.Printf("%-25s %-15s %-15s %-15s %-10s\n",
+			truncate(c.Label, 25),
+			stats.FormatBytes(c.Previous),
+			stats.FormatBytes(c.Current),
+			formatDelta(c.Delta),
+			formatChange(c.Current, c.Previous))
+	}
+}
+
+// periodRanges returns this day, week or month so far and the same stretch
+// of the previous one, with their labels.
+func periodRanges(period string) (previous, current stats.TimeRange, previousLabel, currentLabel string, ok bool) {
+	rangeName := period
+	switch period {
+	case "day":
+		rangeName = "today"
+		previousLabel = "the same time yesterday"
+	case "week":
+		previousLabel = "the same stretch of last week"
+	case "month":
+		previousLabel = "the same stretch of last month"
+	default:
+		return previous, current, "", "", false
+	}
+
+	startTime, label, _ := resolveRange(rangeName)
+	endTime := time.Now().Unix()
+	windows, _ := previousWindows(rangeName, startTime, endTime)
+
+	current = stats.TimeRange{Start: startTime, End: endTime}
+	previous = stats.TimeRange{Start: windows[0].start, End: windows[0].end}
+	return previous, current, previousLabel, label + " so far", true
+}
+
+// parseDateRange parses a range of local dates, "2026-10-01..2026-10-07", or
+// a single date. Both dates are included in full.
+func parseDateRange(s string) (stats.TimeRange, error) {
+	if s == "" {
+		return stats.TimeRange{}, fmt.Errorf("missing range (e.g. 2026-10-01..2026-10-07)")
+	}
+
+	from, to, found := strings.Cut(s, "..")
+	if !found {
+		to = from
+	}
+	start, err := time.ParseInLocation("2006-01-02", from, time.Local)
+	if err != nil {
+		return stats.TimeRange{}, fmt.Errorf("invalid date %q (want YYYY-MM-DD)", from)
+	}
+	end, err := time.ParseInLocation("2006-01-02", to, time.Local)
+	if err != nil {
+		return stats.TimeRange{}, fmt.Errorf("invalid date %q (want YYYY-MM-DD)", to)
+	}
+	if end.Before(start) {
+		return stats.TimeRange{}, fmt.Errorf("range %q ends before it starts", s)
+	}
+
+	return stats.TimeRange{Start: start.Unix(), End: end.AddDate(0, 0, 1).Unix() - 1}, nil
+}
+
+func formatTimeRange(r stats.TimeRange) string {
+	return time.Unix(r.Start, 0).Format("2006-01-02 15:04") + " – " + time.Unix(r.End, 0).Format("2006-01-02 15:04")
+}
+
+// formatDelta formats a change in bytes with its sign.
+func formatDelta(bytes int64) string {
+	if bytes > 0 {
+		return "+" + stats.FormatBytes(uint64(bytes))
+	}
+	return formatSignedBytes(bytes)
+}
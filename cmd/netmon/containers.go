@@ -0,0 +1,133 @@
+package main
+
+import (
+	"flag"
+	"fmt"
+	"netmon/internal/db"
+	"netmon/internal/stats"
+	"os"
+	"sort"
+	"strings"
+	"time"
+)
+
+// shortContainerIDLength matches the abbreviated IDs shown by docker and podman.
+const shortContainerIDLength = 12
+
+// showStatsContainers shows traffic per container (or systemd unit) for a range.
+func showStatsContainers(database *db.DB, args []string) {
+	rangeName := "today"
+	if len(args) > 0 && !strings.HasPrefix(args[0], "-") {
+		rangeName, args = args[0], args[1:]
+	}
+
+	fs := flag.NewFlagSet("netmon stats containers", flag.ExitOnError)
+	units := fs.Bool("units", false, "Group by systemd unit instead of container")
+	fs.Parse(args)
+
+	startTime, label, ok := resolveRange(rangeName)
+	if !ok {
+		fmt.Fprintf(os.Stderr, "Unknown range: %s\n", rangeName)
+		printUsage()
+		os.Exit(1)
+	}
+	endTime := time.Now().Unix()
+
+	heading, column, groupBy, fallback := "container", "Container", db.ColumnContainer, stats.HostLabel
+	if *units {
+		heading, column, groupBy, fallback = "systemd unit", "Unit", db.ColumnSystemdUnit, stats.NoUnitLabel
+	}
+
+	totals, err := database.GetAppGroupTotals(startTime, endTime, groupBy, db.AppLogFilter{})
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "Error fetching app logs: %v\n", err)
+		os.Exit(1)
+	}
+
+	netnsTotals, err := database.GetNetnsTotals(startTime, endTime)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "Error fetching namespace logs: %v\n", err)
+		os.Exit(1)
+	}
+
+	if len(totals) == 0 && len(netnsTotals) == 0 {
+		fmt.Printf("No application data available for %s\n", label)
+		fmt.Println("Make sure netmon-service is running")
+		return
+	}
+
+	containers := stats.GroupSummaries(totals, fallback)
+	sortGroupSummaries(containers)
+
+	fmt.Printf("Stats by %s (%s)\n", heading, label)
+	fmt.Println()
+	printCoverage(database, startTime, endTime)
+	fmt.Println()
+	printContainerTable(column, containers, true)
+
+	if *units || len(netnsTotals) == 0 {
+		return
+	}
+
+	namespaces := stats.ComputeNetnsByContainer(netnsTotals)
+	sortGroupSummaries(namespaces)
+
+	fmt.Println()
+	fmt.Println("Container network namespaces (interface counters)")
+	fmt.Println()
+	printContainerTable(column, namespaces, false)
+}
+
+// printContainerTable prints container summaries, optionally with an app count.
+func printContainerTable(column string, containers []stats.GroupSummary, showApps bool) {
+	if showApps {
+		fmt.Printf("%-40s %-6s %-15s %-15s %-15s\n", column, "Apps", "Downloaded", "Uploaded", "Total")
+		fmt.Println("----------------------------------------------------------------------------------------------")
+	} else {
+		fmt.Printf("%-40s %-15s %-15s %-15s\n", column, "Downloaded", "Uploaded", "Total")
+		fmt.Println("---------------------------------------------------------------------------------------")
+	}
+
+	for _, container := range containers {
+		name := container.Label
+		if isContainerID(name) {
+			name = name[:shortContainerIDLength]
+		}
+
+		total := container.TotalBytesIn + container.TotalBytesOut
+		if showApps {
+			fmt.Printf("%-40s %-6d %-15s %-15s %-15s\n",
+				name,
+				container.Apps,
+				stats.FormatBytes(container.TotalBytesIn),
+				stats.FormatBytes(container.TotalBytesOut),
+				stats.FormatBytes(total))
+			continue
+		}
+		fmt.Printf("%-40s %-15s %-15s %-15s\n",
+			name,
+			stats.FormatBytes(container.TotalBytesIn),
+			stats.FormatBytes(container.TotalBytesOut),
+			stats.FormatBytes(total))
+	}
+}
+
+// isContainerID reports whether s is a full 64-hex-digit container ID.
+func isContainerID(s string) bool {
+	if len(s) != 64 {
+		return false
+	}
+	for _, c := range s {
+		if (c < '0' || c > '9') && (c < 'a' || c > 'f') {
+			return false
+		}
+	}
+	return true
+}
+
+func sortGroupSummaries(containers []stats.GroupSummary) {
+	// Sort by total traffic (descending)
+	sort.Slice(containers, func(i, j int) bool {
+		return containers[i].TotalBytesIn+containers[i].TotalBytesOut > containers[j].TotalBytesIn+containers[j].TotalBytesOut
+	})
+}
@@ -0,0 +1,93 @@
+package main
+
+import (
+	"flag"
+	"fmt"
+	"netmon/internal/db"
+	"netmon/internal/scope"
+	"netmon/internal/stats"
+	"os"
+)
+
+// parseSummaryFlags parses the flags of the range summaries and reports
+// whether only WAN traffic should be counted.
+func parseSummaryFlags(name string, args []string) bool {
+	fs := flag.NewFlagSet(name, flag.ExitOnError)
+	wanOnly := fs.Bool("wan-only", false, "Only count traffic to internet (WAN) addresses")
+	fs.Parse(args)
+	return *wanOnly
+}
+
+// rangeSummary computes the traffic summary for a range from the interface
+// counters or, with wanOnly, from the app traffic attributed to WAN
+// destinations. Peaks are taken over the combined traffic of each sample.
+// The boolean is false if there is no data.
+func rangeSummary(database *db.DB, startTime, endTime int64, wanOnly bool) (stats.Summary, bool) {
+	q := stats.Query{Start: startTime, End: endTime, Metrics: []stats.Metric{stats.Sum, stats.PeakRate}}
+	if wanOnly {
+		q.Scope = scope.WAN
+	}
+
+	groups, err := stats.Run(database, q)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "Error fetching logs: %v\n", err)
+		os.Exit(1)
+	}
+	if len(groups) == 0 {
+		return stats.Summary{}, false
+	}
+	return stats.SummaryOf(groups[0]), true
+}
+
+// printWANOnlyNote explains where WAN-only totals come from.
+func printWANOnlyNote(wanOnly bool) {
+	if wanOnly {
+		fmt.Println("Internet (WAN) traffic only, from per-app attribution")
+	}
+}
+
+// printDestinations prints how the app-attributed traffic of a range splits
+// across destination scopes and address families. Nothing is printed if no
+// traffic was attributed to apps.
+func printDestinations(database *db.DB, startTime, endTime int64, wanOnly bool) {
+	if wanOnly {
+		return
+	}
+
+	scopeTotals, err := database.GetAppGroupTotals(startTime, endTime, db.ColumnScope, db.AppLogFilter{})
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "Error fetching app logs: %v\n", err)
+		os.Exit(1)
+	}
+	if len(scopeTotals) == 0 {
+		return
+	}
+
+	familyTotals, err := database.GetAppGroupTotals(startTime, endTime, db.ColumnFamily, db.AppLogFilter{})
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "Error fetching app logs: %v\n", err)
+		os.Exit(1)
+	}
+
+	scopes := stats.GroupSummaries(scopeTotals, stats.UnknownScopeLabel)
+	families := stats.GroupSummaries(familyTotals, stats.UnknownScopeLabel)
+	sortGroupSummaries(scopes)
+	sortGroupSummaries(families)
+
+	fmt.Println()
+	fmt.Println("By destination (from per-app attribution):")
+	printGroupLines(scopes)
+	fmt.Println()
+	fmt.Println("By address family:")
+	printGroupLines(families)
+}
+
+// printGroupLines prints one indented line per group with its traffic.
+func printGroupLines(groups []stats.GroupSummary) {
+	for _, group := range groups {
+		fmt.Printf("  %-10s %-12s down  %-12s up\n",
+			group.Label,
+			stats.FormatBytes(group.TotalBytesIn),
+			stats.FormatBytes(group.TotalBytesOut))
+	}
+}
@@ -0,0 +1,105 @@
+package main
+
+import (
+	"flag"
+	"fmt"
+	"netmon/internal/db"
+	"netmon/internal/stats"
+	"os"
+	"time"
+)
+
+// handleGaps lists outage windows in which no samples were recorded.
+func handleGaps(database *db.DB, args []string) {
+	rangeName := "today"
+	if len(args) > 0 {
+		rangeName, args = args[0], args[1:]
+	}
+
+	fs := flag.NewFlagSet("netmon gaps", flag.ExitOnError)
+	minDuration := fs.Duration("min", 0, "Only list gaps at least this long")
+	fs.Parse(args)
+
+	startTime, label, ok := resolveRange(rangeName)
+	if !ok {
+		fmt.Fprintf(os.Stderr, "Unknown range: %s\n", rangeName)
+		printUsage()
+		os.Exit(1)
+	}
+
+	coverage, err := computeCoverage(database, startTime, time.Now().Unix())
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "Error fetching samples: %v\n", err)
+		os.Exit(1)
+	}
+
+	suspends, err := database.GetEventsInRange(startTime, time.Now().Unix(), db.EventSuspend)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "Error fetching events: %v\n", err)
+		os.Exit(1)
+	}
+
+	fmt.Printf("Data gaps (%s)\n", label)
+	fmt.Println()
+	fmt.Printf("Data coverage: %.1f%%\n", coverage.Percent())
+	fmt.Println()
+
+	listed := 0
+	for _, gap := range coverage.Gaps {
+		if gap.Duration() < *minDuration {
+			continue
+		}
+		if listed == 0 {
+			fmt.Printf("%-20s %-20s %-15s %-10s\n", "From", "To", "Duration", "Reason")
+			fmt.Println("------------------------------------------------------------------")
+		}
+
+		reason := ""
+		for _, event := range suspends {
+			if event.Timestamp >= gap.Start && event.Timestamp <= gap.End {
+				reason = "asleep"
+				break
+			}
+		}
+
+		fmt.Printf("%-20s %-20s %-15s %-10s\n",
+			time.Unix(gap.Start, 0).Format("2006-01-02 15:04:05"),
+			time.Unix(gap.End, 0).Format("2006-01-02 15:04:05"),
+			gap.Duration(),
+			reason)
+		listed++
+	}
+
+	if listed == 0 {
+		fmt.Println("No gaps found")
+	}
+}
+
+// computeCoverage finds the gaps between samples in [startTime, endTime].
+func computeCoverage(database *db.DB, startTime, endTime int64) (stats.Coverage, error) {
+	return stats.ComputeCoverage(database, startTime, endTime, sampleInterval(database))
+}
+
+// sampleInterval returns the collector's interval in seconds, defaulting to 1.
+func sampleInterval(database *db.DB) int64 {
+	health, ok, err := database.GetHealth()
+	if err != nil || !ok || health.IntervalSeconds <= 0 {
+		return 1
+	}
+	return health.IntervalSeconds
+}
+
+// printCoverage prints the data coverage line for a summary.
+func printCoverage(database *db.DB, startTime, endTime int64) {
+	coverage, err := computeCoverage(database, startTime, endTime)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "Error computing coverage: %v\n", err)
+		return
+	}
+
+	fmt.Printf("Data coverage: %.1f%% for this range", coverage.Percent())
+	if len(coverage.Gaps) > 0 {
+		fmt.Printf(" (%d gaps, see: netmon gaps)", len(coverage.Gaps))
+	}
+	fmt.Println()
+}
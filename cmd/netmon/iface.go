@@ -0,0 +1,226 @@
+package main
+
+import (
+	"fmt"
+	"netmon/internal/collector"
+	"netmon/internal/db"
+	"netmon/internal/stats"
+	"os"
+	"sort"
+	"strings"
+	"time"
+)
+
+// ifaceTopApps is how many apps the interface drill-down lists.
+const ifaceTopApps = 10
+
+// handleIface shows one interface in detail: its link details and error
+// counters as they are now, and its traffic, utilization, timeline and top
+// apps in a range.
+func handleIface(database *db.DB, args []string) {
+	if len(args) < 1 {
+		fmt.Fprintln(os.Stderr, "Usage: netmon iface <name> [range]")
+		os.Exit(1)
+	}
+	name := args[0]
+
+	rangeName := "today"
+	if len(args) > 1 {
+		rangeName = args[1]
+	}
+	startTime, label, ok := resolveRange(rangeName)
+	if !ok {
+		fmt.Fprintf(os.Stderr, "Unknown range: %s\n", rangeName)
+		printUsage()
+		os.Exit(1)
+	}
+	endTime := time.Now().Unix()
+
+	groups, err := stats.Run(database, stats.Query{
+		Start:     startTime,
+		End:       endTime,
+		Interface: name,
+		Metrics:   []stats.Metric{stats.Sum, stats.PeakRate, stats.AvgRate, stats.ActiveTime},
+	})
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "Error fetching logs: %v\n", err)
+		os.Exit(1)
+	}
+
+	link, linkErr := collector.ReadLinkInfo(name)
+	if linkErr != nil && len(groups) == 0 {
+		fmt.Fprintf(os.Stderr, "Error: no interface named %q", name)
+		if names, err := collector.InterfaceNames(); err == nil {
+			fmt.Fprintf(os.Stderr, " (interfaces: %s)", strings.Join(names, ", "))
+		}
+		fmt.Fprintln(os.Stderr)
+		os.Exit(1)
+	}
+
+	fmt.Printf("%s (%s)\n", name, label)
+	if linkErr != nil {
+		fmt.Println("  Not present on this host now")
+	} else {
+		printLinkInfo(link)
+	}
+	fmt.Println()
+
+	if len(groups) == 0 {
+		fmt.Printf("No data available for %s\n", label)
+		return
+	}
+	g := groups[0]
+
+	fmt.Printf("  Downloaded: %s\n", stats.FormatBytes(g.In.Bytes))
+	fmt.Printf("  Uploaded:   %s\n", stats.FormatBytes(g.Out.Bytes))
+	fmt.Printf("  Total:      %s\n", stats.FormatBytes(g.Total()))
+	fmt.Printf("  Peak Down:  %s%s%s\n", stats.FormatBytesPerSec(g.In.PeakRate), utilization(g.In.PeakRate, link.SpeedMbps), peakTime(g.In))
+	fmt.Printf("  Peak Up:    %s%s%s\n", stats.FormatBytesPerSec(g.Out.PeakRate), utilization(g.Out.PeakRate, link.SpeedMbps), peakTime(g.Out))
+	fmt.Printf("  Avg Down:   %s%s\n", stats.FormatBytesPerSec(g.In.AvgRate), utilization(g.In.AvgRate, link.SpeedMbps))
+	fmt.Printf("  Avg Up:     %s%s\n", stats.FormatBytesPerSec(g.Out.AvgRate), utilization(g.Out.AvgRate, link.SpeedMbps))
+	fmt.Printf("  Active:     %s (%.1f%% of the range)\n", time.Duration(g.ActiveSeconds)*time.Second,
+		float64(g.ActiveSeconds)*100/float64(endTime-startTime+1))
+	fmt.Println()
+	printCoverage(database, startTime, endTime)
+	fmt.Println()
+
+	printIfaceTimeline(database, name, rangeName, startTime, endTime, link.SpeedMbps)
+	fmt.Println()
+	printIfaceApps(database, name, startTime, endTime)
+}
+
+// printLinkInfo shows the details of an interface as it is now.
+func printLinkInfo(link collector.LinkInfo) {
+	linkType := link.Type
+	if linkType == "" {
+		linkType = "unknown"
+	}
+	state := "down"
+	if link.Up {
+		state = "up"
+	}
+	speed := "unknown"
+	if link.SpeedMbps > 0 {
+		speed = fmt.Sprintf("%d Mb/s", link.SpeedMbps)
+	}
+
+	fmt.Printf("  Type:       %s\n", linkType)
+	fmt.Printf("  State:      %s\n", state)
+	if link.MAC != "" {
+		fmt.Printf("  MAC:        %s\n", link.MAC)
+	}
+	fmt.Printf("  MTU:        %d\n", link.MTU)
+	fmt.Printf("  Link speed: %s\n", speed)
+	if len(link.Addresses) == 0 {
+		fmt.Println("  Addresses:  none")
+	}
+	for i, addr := range link.Addresses {
+		if i == 0 {
+			fmt.Printf("  Addresses:  %s\n", addr)
+		} else {
+			fmt.Printf("              %s\n", addr)
+		}
+	}
+	fmt.Printf("  Errors:     %d in, %d out (since the interface came up)\n", link.ErrorsIn, link.ErrorsOut)
+	fmt.Printf("  Drops:      %d in, %d out\n", link.DropsIn, link.DropsOut)
+}
+
+// utilization formats a rate as a share of the link speed, if it is known.
+func utilization(rate uint64, speedMbps int64) string {
+	if speedMbps <= 0 {
+		return ""
+	}
+	return fmt.Sprintf(" (%.1f%% of link speed)", utilizationPercent(rate, speedMbps))
+}
+
+// utilizationPercent returns a rate in bytes per second as a percentage of a
+// link speed in megabits per second.
+func utilizationPercent(rate uint64, speedMbps int64) float64 {
+	return float64(rate) * 8 * 100 / (float64(speedMbps) * 1e6)
+}
+
+// printIfaceTimeline shows the interface's traffic per hour for today and
+// per day for longer ranges, with the average utilization of the busier
+// direction if the link speed is known.
+func printIfaceTimeline(database *db.DB, name, rangeName string, startTime, endTime, speedMbps int64) {
+	dimension := stats.ByDay
+	if rangeName == "today" {
+		dimension = stats.ByHour
+	}
+
+	groups, err := stats.Run(database, stats.Query{
+		Start:     startTime,
+		End:       endTime,
+		Interface: name,
+		GroupBy:   []stats.Dimension{dimension},
+		Metrics:   []stats.Metric{stats.Sum, stats.AvgRate},
+	})
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "Error fetching logs: %v\n", err)
+		os.Exit(1)
+	}
+
+	fmt.Println("Timeline:")
+	fmt.Printf("%-16s %-15s %-15s %-15s %-15s %-15s %-10s\n",
+		dimensionTitle(dimension), "Downloaded", "Uploaded", "Total", "Avg down", "Avg up", "Avg util")
+	fmt.Println("----------------------------------------------------------------------------------------------------------")
+	for _, g := range groups {
+		util := "-"
+		if speedMbps > 0 {
+			util = fmt.Sprintf("%.1f%%", utilizationPercent(max(g.In.AvgRate, g.Out.AvgRate), speedMbps))
+		}
+		fmt.Printf("%-16s %-15s %-15s %-15s %-15s %-15s %-10s\n",
+			g.Labels[0],
+			stats.FormatBytes(g.In.Bytes),
+			stats.FormatBytes(g.Out.Bytes),
+			stats.FormatBytes(g.Total()),
+			stats.FormatBytesPerSec(g.In.AvgRate),
+			stats.FormatBytesPerSec(g.Out.AvgRate),
+			util)
+	}
+}
+
+// printIfaceApps lists the apps with connections through the interface in
+// the range, by their traffic. App traffic is not recorded per interface, so
+// their totals include traffic over other interfaces.
+func printIfaceApps(database *db.DB, name string, startTime, endTime int64) {
+	apps, err := database.GetInterfaceApps(name, startTime, endTime)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "Error fetching apps: %v\n", err)
+		os.Exit(1)
+	}
+	if len(apps) == 0 {
+		fmt.Println("No apps recorded with connections through this interface")
+		return
+	}
+
+	groups, err := stats.Run(database, stats.Query{
+		Start:   startTime,
+		End:     endTime,
+		GroupBy: []stats.Dimension{stats.ByApp},
+	})
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "Error fetching logs: %v\n", err)
+		os.Exit(1)
+	}
+	totals := make(map[string]uint64, len(groups))
+	for _, g := range groups {
+		totals[g.Keys[0]] = g.Total()
+	}
+
+	// Sort by total traffic (descending)
+	sort.Slice(apps, func(i, j int) bool {
+		return totals[apps[i].AppKey] > totals[apps[j].AppKey]
+	})
+
+	fmt.Println("Top apps (traffic on all interfaces):")
+	fmt.Printf("%-25s %-15s %-10s\n", "Application", "Total", "Hosts")
+	fmt.Println("----------------------------------------------------")
+	for i, app := range apps {
+		if i == ifaceTopApps {
+			fmt.Printf("... and %d more\n", len(apps)-ifaceTopApps)
+			break
+		}
+		fmt.Printf("%-25s %-15s %-10d\n", truncate(app.AppName, 25), stats.FormatBytes(totals[app.AppKey]), app.Hosts)
+	}
+}
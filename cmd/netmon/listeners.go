@@ -0,0 +1,75 @@
+package main
+
+import (
+	"flag"
+	"fmt"
+	"net"
+	"netmon/internal/db"
+	"os"
+	"strconv"
+	"time"
+)
+
+// listenerActiveWindow is how recently a listener must have been seen to
+// count as open. netmon-service refreshes listeners once a minute.
+const listenerActiveWindow = 2 * time.Minute
+
+// handleListeners shows the inventory of ports apps listen on.
+func handleListeners(database *db.DB, args []string) {
+	fs := flag.NewFlagSet("netmon listeners", flag.ExitOnError)
+	all := fs.Bool("all", false, "Include listeners that have since closed")
+	fs.Parse(args)
+
+	now := time.Now()
+	since := now.Add(-listenerActiveWindow).Unix()
+	if *all {
+		since = 0
+	}
+
+	listeners, err := database.GetListeners(since)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "Error fetching listeners: %v\n", err)
+		os.Exit(1)
+	}
+
+	if *all {
+		fmt.Println("Listening ports (all seen)")
+	} else {
+		fmt.Println("Listening ports (open now)")
+	}
+	fmt.Println()
+
+	if len(listeners) == 0 {
+		fmt.Println("No listeners recorded")
+		fmt.Println("Make sure netmon-service is running")
+		return
+	}
+
+	fmt.Printf("%-25s %-8s %-6s %-30s %-20s %-20s\n", "Application", "PID", "Proto", "Address", "First seen", "Last seen")
+	fmt.Println("------------------------------------------------------------------------------------------------------------------")
+
+	for _, l := range listeners {
+		fmt.Printf("%-25s %-8d %-6s %-30s %-20s %-20s\n",
+			l.AppName,
+			l.PID,
+			l.Transport,
+			net.JoinHostPort(l.Address, strconv.Itoa(int(l.Port))),
+			time.Unix(l.FirstSeen, 0).Format("2006-01-02 15:04:05"),
+			time.Unix(l.LastSeen, 0).Format("2006-01-02 15:04:05"))
+	}
+
+	events, err := database.GetEventsInRange(now.Add(-24*time.Hour).Unix(), now.Unix(), db.EventListener)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "Error fetching events: %v\n", err)
+		os.Exit(1)
+	}
+	if len(events) == 0 {
+		return
+	}
+
+	fmt.Println()
+	fmt.Println("Opened in the last 24 hours:")
+	for _, event := range events {
+		fmt.Printf("  %s  %s\n", time.Unix(event.Timestamp, 0).Format("2006-01-02 15:04:05"), event.Detail)
+	}
+}
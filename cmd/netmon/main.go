@@ -5,10 +5,11 @@ import (
 	"flag"
 	"fmt"
 	"netmon/internal/db"
+	"netmon/internal/rules"
 	"netmon/internal/stats"
 	"os"
-	"os/exec"
 	"path/filepath"
+	"sort"
 	"strings"
 	"time"
 )
@@ -34,18 +35,28 @@ func main() {
 		}
 		defer database.Close()
 
-		showStatsApps(database)
+		warnIfUnhealthy(database)
+		showStatsApps(database, loadRules(rules.DefaultPath()), nil)
 		return
 	}
 
 	command := os.Args[1]
 
+	// setup has its own flags and opens the database itself
+	if command == "setup" {
+		handleSetup(os.Args[2:])
+		return
+	}
+
 	// Parse global flags
 	fs := flag.NewFlagSet("netmon", flag.ExitOnError)
 	dbPath := fs.String("db", getDefaultDBPath(), "Path to SQLite database file")
+	rulesPath := fs.String("rules", rules.DefaultPath(), "Path to app grouping rules file")
 
-	// Skip the command name when parsing flags
-	fs.Parse(os.Args[2:])
+	// Skip the command name when parsing flags. Global flags may be mixed
+	// with the command's own, which are passed on with its arguments.
+	globalArgs, args := splitGlobalFlags(fs, os.Args[2:])
+	fs.Parse(globalArgs)
 
 	// Open database
 	database, err := db.Open(*dbPath)
@@ -55,21 +66,39 @@ func main() {
 	}
 	defer database.Close()
 
+	// service status reports collector health itself
+	if command != "service" && command != "version" {
+		warnIfUnhealthy(database)
+	}
+
 	// Execute command
 	switch command {
-	case "setup":
-		handleSetup()
-		return
 	case "version":
 		showVersion()
 		return
+	case "service":
+		handleService(database, *dbPath, args)
+		return
 	case "stats":
 		// If "stats" with no subcommand, default to apps
-		if fs.NArg() < 1 {
-			showStatsApps(database)
+		ruleSet := loadRules(*rulesPath)
+		if len(args) < 1 {
+			showStatsApps(database, ruleSet, nil)
 			return
 		}
-		handleStats(database, fs.Arg(0))
+		handleStats(database, ruleSet, args[0], args[1:])
+	case "gaps":
+		handleGaps(database, args)
+	case "listeners":
+		handleListeners(database, args)
+	case "query":
+		handleQuery(database, args)
+	case "app":
+		handleApp(database, loadRules(*rulesPath), args)
+	case "iface":
+		handleIface(database, args)
+	case "compare":
+		handleCompare(database, args)
 	default:
 		fmt.Fprintf(os.Stderr, "Unknown command: %s\n", command)
 		printUsage()
@@ -77,20 +106,57 @@ func main() {
 	}
 }
 
-func handleStats(database *db.DB, subcommand string) {
+// splitGlobalFlags separates the flags defined in fs, with their values,
+// from the other arguments, keeping the order of both.
+func splitGlobalFlags(fs *flag.FlagSet, args []string) (global, rest []string) {
+	for i := 0; i < len(args); i++ {
+		arg := args[i]
+		name := strings.TrimLeft(arg, "-")
+		if !strings.HasPrefix(arg, "-") || name == "" {
+			rest = append(rest, arg)
+			continue
+		}
+
+		name, _, hasValue := strings.Cut(name, "=")
+		if fs.Lookup(name) == nil {
+			rest = append(rest, arg)
+			continue
+		}
+		global = append(global, arg)
+		if !hasValue && i+1 < len(args) {
+			i++
+			global = append(global, args[i])
+		}
+	}
+	return global, rest
+}
+
+func handleStats(database *db.DB, ruleSet *rules.Set, subcommand string, args []string) {
 	switch subcommand {
 	case "today":
-		showStatsToday(database)
+		showStatsToday(database, args)
 	case "week":
-		showStatsWeek(database)
+		showStatsWeek(database, args)
 	case "month":
-		showStatsMonth(database)
+		showStatsMonth(database, args)
 	case "all":
-		showStatsAll(database)
+		showStatsAll(database, args)
 	case "interfaces":
 		showStatsInterfaces(database)
 	case "apps":
-		showStatsApps(database)
+		showStatsApps(database, ruleSet, args)
+	case "categories":
+		showStatsCategories(database, ruleSet)
+	case "users":
+		showStatsUsers(database, args)
+	case "containers":
+		showStatsContainers(database, args)
+	case "namespaces":
+		showStatsNamespaces(database, args)
+	case "protocols":
+		showStatsProtocols(database, args)
+	case "reconcile":
+		showStatsReconcile(database, args)
 	default:
 		fmt.Fprintf(os.Stderr, "Unknown stats subcommand: %s\n", subcommand)
 		printUsage()
@@ -98,24 +164,36 @@ func handleStats(database *db.DB, subcommand string) {
 	}
 }
 
-func showStatsToday(database *db.DB) {
+// resolveRange maps a range name to its start time and a display label.
+func resolveRange(name string) (int64, string, bool) {
+	switch name {
+	case "today":
+		return db.GetStartOfDay(), "today", true
+	case "week":
+		return db.GetStartOfWeek(), "this week", true
+	case "month":
+		return db.GetStartOfMonth(), "this month", true
+	case "all":
+		return db.GetStartOfAllTime(), "all time", true
+	default:
+		return 0, "", false
+	}
+}
+
+func showStatsToday(database *db.DB, args []string) {
+	wanOnly := parseSummaryFlags("netmon stats today", args)
+
 	startTime := db.GetStartOfDay()
 	endTime := time.Now().Unix()
 
-	logs, err := database.GetLogsInRange(startTime, endTime)
-	if err != nil {
-		fmt.Fprintf(os.Stderr, "Error fetching logs: %v\n", err)
-		os.Exit(1)
-	}
-
-	if len(logs) == 0 {
+	summary, ok := rangeSummary(database, startTime, endTime, wanOnly)
+	if !ok {
 		fmt.Println("No data available for today")
 		return
 	}
 
-	summary := stats.ComputeSummary(logs)
-
 	fmt.Println("Stats for today")
+	printWANOnlyNote(wanOnly)
 	fmt.Println()
 	fmt.Println("Overall Totals:")
 	fmt.Printf("  Downloaded: %s\n", stats.FormatBytes(summary.TotalBytesIn))
@@ -124,26 +202,25 @@ func showStatsToday(database *db.DB) {
 	fmt.Println()
 	fmt.Printf("Peak Down:  %s\n", stats.FormatBytesPerSec(summary.PeakBytesIn))
 	fmt.Printf("Peak Up:    %s\n", stats.FormatBytesPerSec(summary.PeakBytesOut))
+	fmt.Println()
+	printCoverage(database, startTime, endTime)
+	printDestinations(database, startTime, endTime, wanOnly)
 }
 
-func showStatsWeek(database *db.DB) {
+func showStatsWeek(database *db.DB, args []string) {
+	wanOnly := parseSummaryFlags("netmon stats week", args)
+
 	startTime := db.GetStartOfWeek()
 	endTime := time.Now().Unix()
 
-	logs, err := database.GetLogsInRange(startTime, endTime)
-	if err != nil {
-		fmt.Fprintf(os.Stderr, "Error fetching logs: %v\n", err)
-		os.Exit(1)
-	}
-
-	if len(logs) == 0 {
+	summary, ok := rangeSummary(database, startTime, endTime, wanOnly)
+	if !ok {
 		fmt.Println("No data available for this week")
 		return
 	}
 
-	summary := stats.ComputeSummary(logs)
-
 	fmt.Println("Stats for this week (Monday - now)")
+	printWANOnlyNote(wanOnly)
 	fmt.Println()
 	fmt.Println("Overall Totals:")
 	fmt.Printf("  Downloaded: %s\n", stats.FormatBytes(summary.TotalBytesIn))
@@ -152,27 +229,26 @@ func showStatsWeek(database *db.DB) {
 	fmt.Println()
 	fmt.Printf("Peak Down:  %s\n", stats.FormatBytesPerSec(summary.PeakBytesIn))
 	fmt.Printf("Peak Up:    %s\n", stats.FormatBytesPerSec(summary.PeakBytesOut))
+	fmt.Println()
+	printCoverage(database, startTime, endTime)
+	printDestinations(database, startTime, endTime, wanOnly)
 }
 
-func showStatsMonth(database *db.DB) {
+func showStatsMonth(database *db.DB, args []string) {
+	wanOnly := parseSummaryFlags("netmon stats month", args)
+
 	startTime := db.GetStartOfMonth()
 	endTime := time.Now().Unix()
 
-	logs, err := database.GetLogsInRange(startTime, endTime)
-	if err != nil {
-		fmt.Fprintf(os.Stderr, "Error fetching logs: %v\n", err)
-		os.Exit(1)
-	}
-
-	if len(logs) == 0 {
+	summary, ok := rangeSummary(database, startTime, endTime, wanOnly)
+	if !ok {
 		fmt.Println("No data available for this month")
 		return
 	}
 
-	summary := stats.ComputeSummary(logs)
-
 	now := time.Now()
 	fmt.Printf("Stats for %s\n", now.Format("January 2006"))
+	printWANOnlyNote(wanOnly)
 	fmt.Println()
 	fmt.Println("Overall Totals:")
 	fmt.Printf("  Downloaded: %s\n", stats.FormatBytes(summary.TotalBytesIn))
@@ -181,26 +257,25 @@ func showStatsMonth(database *db.DB) {
 	fmt.Println()
 	fmt.Printf("Peak Down:  %s\n", stats.FormatBytesPerSec(summary.PeakBytesIn))
 	fmt.Printf("Peak Up:    %s\n", stats.FormatBytesPerSec(summary.PeakBytesOut))
+	fmt.Println()
+	printCoverage(database, startTime, endTime)
+	printDestinations(database, startTime, endTime, wanOnly)
 }
 
-func showStatsAll(database *db.DB) {
+func showStatsAll(database *db.DB, args []string) {
+	wanOnly := parseSummaryFlags("netmon stats all", args)
+
 	startTime := db.GetStartOfAllTime()
 	endTime := time.Now().Unix()
 
-	logs, err := database.GetLogsInRange(startTime, endTime)
-	if err != nil {
-		fmt.Fprintf(os.Stderr, "Error fetching logs: %v\n", err)
-		os.Exit(1)
-	}
-
-	if len(logs) == 0 {
+	summary, ok := rangeSummary(database, startTime, endTime, wanOnly)
+	if !ok {
 		fmt.Println("No data available")
 		return
 	}
 
-	summary := stats.ComputeSummary(logs)
-
 	fmt.Println("Stats for all time")
+	printWANOnlyNote(wanOnly)
 	fmt.Println()
 	fmt.Println("Overall Totals:")
 	fmt.Printf("  Downloaded: %s\n", stats.FormatBytes(summary.TotalBytesIn))
@@ -209,24 +284,27 @@ func showStatsAll(database *db.DB) {
 	fmt.Println()
 	fmt.Printf("Peak Down:  %s\n", stats.FormatBytesPerSec(summary.PeakBytesIn))
 	fmt.Printf("Peak Up:    %s\n", stats.FormatBytesPerSec(summary.PeakBytesOut))
+	fmt.Println()
+	printCoverage(database, startTime, endTime)
+	printDestinations(database, startTime, endTime, wanOnly)
 }
 
 func showStatsInterfaces(database *db.DB) {
 	startTime := db.GetStartOfDay()
 	endTime := time.Now().Unix()
 
-	logsByInterface, err := database.GetLogsByInterface(startTime, endTime)
+	groups, err := stats.Run(database, stats.Query{Start: startTime, End: endTime, GroupBy: []stats.Dimension{stats.ByInterface}})
 	if err != nil {
 		fmt.Fprintf(os.Stderr, "Error fetching logs: %v\n", err)
 		os.Exit(1)
 	}
 
-	if len(logsByInterface) == 0 {
+	if len(groups) == 0 {
 		fmt.Println("No data available for today")
 		return
 	}
 
-	summaries := stats.ComputeByInterface(logsByInterface)
+	summaries := stats.InterfaceSummaries(groups)
 
 	// Calculate overall totals
 	var totalIn, totalOut uint64
@@ -242,6 +320,8 @@ func showStatsInterfaces(database *db.DB) {
 	fmt.Printf("  Uploaded:   %s\n", stats.FormatBytes(totalOut))
 	fmt.Printf("  Total:      %s\n", stats.FormatBytes(totalIn+totalOut))
 	fmt.Println()
+	printCoverage(database, startTime, endTime)
+	fmt.Println()
 	fmt.Printf("%-20s %-15s %-15s %-15s\n", "Interface", "Downloaded", "Uploaded", "Total")
 	fmt.Println("-------------------------------------------------------------------")
 
@@ -255,24 +335,35 @@ func showStatsInterfaces(database *db.DB) {
 	}
 }
 
-func showStatsApps(database *db.DB) {
+func showStatsApps(database *db.DB, ruleSet *rules.Set, args []string) {
+	fs := flag.NewFlagSet("netmon stats apps", flag.ExitOnError)
+	tree := fs.Bool("tree", false, "Nest child processes under the app that spawned them")
+	rollup := fs.Bool("rollup", false, "Attribute child process traffic to the app that spawned them")
+	user := fs.String("user", "", "Only count traffic of this user (name or UID)")
+	protocol := fs.String("protocol", "", "Only count traffic of this protocol (e.g. HTTPS, DNS, QUIC)")
+	fs.Parse(args)
+	filter := appFilter{user: *user, protocol: *protocol}
+
 	startTime := db.GetStartOfDay()
 	endTime := time.Now().Unix()
 
-	logsByApp, err := database.GetAppLogsByName(startTime, endTime)
+	if *tree || *rollup {
+		showStatsAppTree(database, ruleSet, startTime, endTime, *tree, filter)
+		return
+	}
+
+	summaries, err := appSummaries(database, ruleSet, startTime, endTime, filter)
 	if err != nil {
 		fmt.Fprintf(os.Stderr, "Error fetching app logs: %v\n", err)
 		os.Exit(1)
 	}
 
-	if len(logsByApp) == 0 {
+	if len(summaries) == 0 {
 		fmt.Println("No application data available for today")
 		fmt.Println("Make sure netmon-service is running")
 		return
 	}
 
-	summaries := stats.ComputeByApp(logsByApp)
-
 	// Sort by total traffic (downloaded + uploaded)
 	sortAppSummaries(summaries)
 
@@ -283,13 +374,15 @@ func showStatsApps(database *db.DB) {
 		totalOut += summary.TotalBytesOut
 	}
 
-	fmt.Println("Stats by application (today)")
+	fmt.Printf("Stats by application (%s)\n", appsViewLabel(filter))
 	fmt.Println()
 	fmt.Println("Overall Totals:")
 	fmt.Printf("  Downloaded: %s\n", stats.FormatBytes(totalIn))
 	fmt.Printf("  Uploaded:   %s\n", stats.FormatBytes(totalOut))
 	fmt.Printf("  Total:      %s\n", stats.FormatBytes(totalIn+totalOut))
 	fmt.Println()
+	printCoverage(database, startTime, endTime)
+	fmt.Println()
 	fmt.Printf("%-30s %-15s %-15s %-15s\n", "Application", "Downloaded", "Uploaded", "Total")
 	fmt.Println("------------------------------------------------------------------------")
 
@@ -303,34 +396,93 @@ func showStatsApps(database *db.DB) {
 	}
 }
 
+// appsViewLabel describes the scope of the apps view for its heading.
+func appsViewLabel(filter appFilter) string {
+	if label := filter.label(); label != "" {
+		return "today, " + label
+	}
+	return "today"
+}
+
 func sortAppSummaries(summaries []stats.AppSummary) {
-	// Simple bubble sort by total traffic (descending)
-	for i := 0; i < len(summaries); i++ {
-		for j := i + 1; j < len(summaries); j++ {
-			totalI := summaries[i].TotalBytesIn + summaries[i].TotalBytesOut
-			totalJ := summaries[j].TotalBytesIn + summaries[j].TotalBytesOut
-			if totalJ > totalI {
-				summaries[i], summaries[j] = summaries[j], summaries[i]
-			}
-		}
+	// Sort by total traffic (descending)
+	sort.Slice(summaries, func(i, j int) bool {
+		return summaries[i].TotalBytesIn+summaries[i].TotalBytesOut > summaries[j].TotalBytesIn+summaries[j].TotalBytesOut
+	})
+}
+
+// warnIfUnhealthy prints a warning to stderr when the collector is stale or failing.
+func warnIfUnhealthy(database *db.DB) {
+	health, ok, err := database.GetHealth()
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "⚠️  Could not read collector health: %v\n\n", err)
+		return
+	}
+	if !ok {
+		fmt.Fprintln(os.Stderr, "⚠️  netmon-service has never run against this database (see: netmon setup)")
+		fmt.Fprintln(os.Stderr)
+		return
+	}
+	if warning := health.Warning(time.Now()); warning != "" {
+		fmt.Fprintf(os.Stderr, "⚠️  %s\n", warning)
+		fmt.Fprintln(os.Stderr, "Check: netmon service status")
+		fmt.Fprintln(os.Stderr)
 	}
 }
 
 func printUsage() {
 	fmt.Println("Usage:")
 	fmt.Println("  netmon setup              Set up background service (run this first!)")
+	fmt.Println("  netmon setup --yes        Set up without prompting (see netmon setup -h)")
 	fmt.Println("  netmon version            Show version information")
+	fmt.Println("  netmon service status     Show service state and when data was last collected")
+	fmt.Println("  netmon service start      Start the background service")
+	fmt.Println("  netmon service stop       Stop the background service")
+	fmt.Println("  netmon service restart    Restart the background service")
+	fmt.Println("  netmon service logs       Follow the service logs")
+	fmt.Println("  netmon service uninstall  Remove the service (--purge also deletes the database)")
 	fmt.Println("  netmon                    Show today's usage by application (default)")
 	fmt.Println("  netmon stats              Show today's usage by application (same as above)")
 	fmt.Println("  netmon stats apps         Show today's usage by application")
+	fmt.Println("                            --tree nests child processes under their parent app")
+	fmt.Println("                            --rollup credits child process traffic to the parent app")
+	fmt.Println("                            --user <name|uid> only counts that user's traffic")
+	fmt.Println("                            --protocol <name> only counts that protocol (e.g. HTTPS)")
 	fmt.Println("  netmon stats today        Show today's total network usage")
+	fmt.Println("                            --wan-only counts internet traffic only (also week, month, all)")
 	fmt.Println("  netmon stats week         Show this week's total network usage")
 	fmt.Println("  netmon stats month        Show this month's total network usage")
 	fmt.Println("  netmon stats all          Show all-time total network usage")
 	fmt.Println("  netmon stats interfaces   Show today's usage by interface")
+	fmt.Println("  netmon stats categories   Show today's usage by app category")
+	fmt.Println("  netmon stats users [range] Show usage per user account (today, week, month, all)")
+	fmt.Println("  netmon stats containers [range]")
+	fmt.Println("                            Show usage per container (Linux); --units groups by systemd unit")
+	fmt.Println("  netmon stats namespaces [range]")
+	fmt.Println("                            Show interface usage inside network namespaces (Linux)")
+	fmt.Println("  netmon stats protocols [range]")
+	fmt.Println("                            Show usage per protocol/service (HTTPS, DNS, SSH, QUIC, ...)")
+	fmt.Println("  netmon stats reconcile [range]")
+	fmt.Println("                            Compare interface traffic with the traffic attributed to apps")
+	fmt.Println("  netmon gaps [range]       List periods with no data (range: today, week, month, all)")
+	fmt.Println("                            --min <duration> hides shorter gaps")
+	fmt.Println("  netmon listeners          Show ports apps are listening on (--all includes closed ones)")
+	fmt.Println("  netmon query [range]      Break traffic down any way (range: today, week, month, all)")
+	fmt.Println("                            --by interface,app,hour,day,weekday groups by those")
+	fmt.Println("                            --metrics sum,peak,avg,active,p95 picks the figures shown")
+	fmt.Println("                            --iface, --app, --user, --protocol and --direction in|out filter")
+	fmt.Println("  netmon app <name> [range] Show one app in detail: timeline, peak, hosts and usual usage")
+	fmt.Println("                            (name may be part of the app's name)")
+	fmt.Println("  netmon iface <name> [range]")
+	fmt.Println("                            Show one interface in detail: link, errors, utilization, top apps")
+	fmt.Println("  netmon compare            Compare this week so far with the same stretch of last week")
+	fmt.Println("                            --period day|week|month picks the period")
+	fmt.Println("                            --previous <dates> --current <dates> compares two ranges")
+	fmt.Println("                            (dates: 2026-10-01..2026-10-07); --json prints JSON")
 	fmt.Println()
 	fmt.Println("Flags:")
 	fmt.Println("  -db <path>               Path to SQLite database (default: ~/.netmon/netmon.db)")
+	fmt.Println("  -rules <path>            App grouping rules (default: ~/.netmon/rules.json)")
 }
 
 // showVersion displays version information
@@ -351,300 +503,6 @@ func getDefaultDBPath() string {
 	return filepath.Join(home, ".netmon", "netmon.db")
 }
 
-// handleSetup runs the interactive setup wizard
-func handleSetup() {
-	fmt.Println("╔════════════════════════════════════════════════════════════════╗")
-	fmt.Println("║                                                                ║")
-	fmt.Println("║                    NETMON SETUP WIZARD                         ║")
-	fmt.Println("║                                                                ║")
-	fmt.Println("╚════════════════════════════════════════════════════════════════╝")
-	fmt.Println()
-
-	// Get current executable path
-	exePath, err := os.Executable()
-	if err != nil {
-		fmt.Fprintf(os.Stderr, "Error: Could not determine executable path: %v\n", err)
-		os.Exit(1)
-	}
-
-	// Resolve symlinks
-	exePath, err = filepath.EvalSymlinks(exePath)
-	if err != nil {
-		fmt.Fprintf(os.Stderr, "Error: Could not resolve executable path: %v\n", err)
-		os.Exit(1)
-	}
-
-	// Get the service binary path (assuming it's in the same directory)
-	serviceExePath := filepath.Join(filepath.Dir(exePath), "netmon-service")
-	menuExePath := filepath.Join(filepath.Dir(exePath), "netmon-menu")
-
-	// Check if service binary exists
-	if _, err := os.Stat(serviceExePath); os.IsNotExist(err) {
-		fmt.Fprintf(os.Stderr, "Error: netmon-service not found at %s\n", serviceExePath)
-		fmt.Println("\nMake sure both netmon and netmon-service are in the same directory.")
-		os.Exit(1)
-	}
-
-	fmt.Printf("Found netmon-service at: %s\n", serviceExePath)
-
-	// Check if menu bar app exists (optional)
-	menuAppExists := false
-	if _, err := os.Stat(menuExePath); err == nil {
-		menuAppExists = true
-		fmt.Printf("Found netmon-menu at: %s\n", menuExePath)
-	}
-	fmt.Println()
-
-	// Check if already installed
-	home, err := os.UserHomeDir()
-	if err != nil {
-		fmt.Fprintf(os.Stderr, "Error: Could not determine home directory: %v\n", err)
-		os.Exit(1)
-	}
-
-	plistPath := filepath.Join(home, "Library", "LaunchAgents", "com.netmon.service.plist")
-
-	if _, err := os.Stat(plistPath); err == nil {
-		// Already installed
-		fmt.Println("⚠️  netmon service is already installed!")
-		fmt.Println()
-		fmt.Print("Do you want to reinstall/update it? (yes/no): ")
-
-		if !promptYesNo() {
-			fmt.Println("\nSetup cancelled.")
-			return
-		}
-
-		// Unload existing service
-		fmt.Println("\nUnloading existing service...")
-		cmd := exec.Command("launchctl", "unload", plistPath)
-		cmd.Run() // Ignore errors, it might not be loaded
-	}
-
-	// Ask user if they want persistent service
-	fmt.Println("Do you want netmon-service to run automatically in the background?")
-	fmt.Println("This will:")
-	fmt.Println("  ✅ Start monitoring on boot")
-	fmt.Println("  ✅ Keep running after restarts")
-	fmt.Println("  ✅ Track network usage 24/7")
-	fmt.Println()
-	fmt.Print("Enable background service? (yes/no): ")
-
-	if !promptYesNo() {
-		fmt.Println("\nBackground service not enabled.")
-		fmt.Println("You can run the service manually with: ./netmon-service")
-		return
-	}
-
-	// Create LaunchAgents directory if it doesn't exist
-	launchAgentsDir := filepath.Join(home, "Library", "LaunchAgents")
-	if err := os.MkdirAll(launchAgentsDir, 0755); err != nil {
-		fmt.Fprintf(os.Stderr, "Error: Could not create LaunchAgents directory: %v\n", err)
-		os.Exit(1)
-	}
-
-	// Create plist content
-	plistContent := fmt.Sprintf(`<?xml version="1.0" encoding="UTF-8"?>
-<!DOCTYPE plist PUBLIC "-//Apple//DTD PLIST 1.0//EN" "http://www.apple.com/DTDs/PropertyList-1.0.dtd">
-<plist version="1.0">
-<dict>
-    <key>Label</key>
-    <string>com.netmon.service</string>
-    
-    <key>ProgramArguments</key>
-    <array>
-        <string>%s</string>
-    </array>
-    
-    <key>RunAtLoad</key>
-    <true/>
-    
-    <key>KeepAlive</key>
-    <true/>
-    
-    <key>StandardOutPath</key>
-    <string>/tmp/netmon-service.log</string>
-    
-    <key>StandardErrorPath</key>
-    <string>/tmp/netmon-service.error.log</string>
-    
-    <key>EnvironmentVariables</key>
-    <dict>
-        <key>PATH</key>
-        <string>/usr/local/bin:/usr/bin:/bin:/usr/sbin:/sbin</string>
-    </dict>
-</dict>
-</plist>
-`, serviceExePath)
-
-	// Write plist file
-	fmt.Println("\nCreating service configuration...")
-	if err := os.WriteFile(plistPath, []byte(plistContent), 0644); err != nil {
-		fmt.Fprintf(os.Stderr, "Error: Could not create plist file: %v\n", err)
-		os.Exit(1)
-	}
-	fmt.Printf("✓ Created: %s\n", plistPath)
-
-	// Load the service
-	fmt.Println("\nLoading and starting service...")
-	cmd := exec.Command("launchctl", "load", plistPath)
-	output, err := cmd.CombinedOutput()
-	if err != nil {
-		fmt.Fprintf(os.Stderr, "Error loading service: %v\n%s\n", err, string(output))
-		os.Exit(1)
-	}
-
-	// Wait a moment for service to start
-	time.Sleep(1 * time.Second)
-
-	// Verify it's running
-	cmd = exec.Command("launchctl", "list", "com.netmon.service")
-	if err := cmd.Run(); err != nil {
-		fmt.Println("⚠️  Service loaded but may not be running properly.")
-		fmt.Println("Check logs: tail -f /tmp/netmon-service.log")
-	} else {
-		fmt.Println("✓ Service loaded and started successfully!")
-	}
-
-	// Ask about menu bar app
-	if menuAppExists {
-		fmt.Println()
-		fmt.Println("━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━")
-		fmt.Println()
-		fmt.Println("Would you like to show network usage in the macOS menu bar?")
-		fmt.Println("This will:")
-		fmt.Println("  ✅ Display today's total network usage in the menu bar")
-		fmt.Println("  ✅ Update every 5 seconds")
-		fmt.Println("  ✅ Show detailed stats on hover")
-		fmt.Println("  ✅ Start automatically on login")
-		fmt.Println()
-		fmt.Print("Enable menu bar app? (yes/no): ")
-
-		if promptYesNo() {
-			setupMenuBarApp(home, menuExePath)
-		} else {
-			fmt.Println("\nMenu bar app not enabled.")
-			fmt.Println("You can run it manually with: ./netmon-menu")
-		}
-	}
-
-	// Success message
-	fmt.Println()
-	fmt.Println("╔════════════════════════════════════════════════════════════════╗")
-	fmt.Println("║                                                                ║")
-	fmt.Println("║                    ✓ SETUP COMPLETE!                           ║")
-	fmt.Println("║                                                                ║")
-	fmt.Println("╚════════════════════════════════════════════════════════════════╝")
-	fmt.Println()
-	fmt.Println("netmon-service is now running in the background!")
-	if menuAppExists {
-		fmt.Println("Menu bar app is configured!")
-	}
-	fmt.Println()
-	fmt.Println("What's next:")
-	fmt.Println("  • View your network usage: netmon")
-	fmt.Println("  • Check service logs:      tail -f /tmp/netmon-service.log")
-	fmt.Println("  • View monthly stats:      netmon stats month")
-	if menuAppExists {
-		fmt.Println("  • Menu bar shows:        Today's total network usage")
-	}
-	fmt.Println()
-	fmt.Println("Management commands:")
-	fmt.Println("  • Stop service:   launchctl stop com.netmon.service")
-	fmt.Println("  • Start service:  launchctl start com.netmon.service")
-	fmt.Println("  • Uninstall:      launchctl unload ~/Library/LaunchAgents/com.netmon.service.plist")
-	if menuAppExists {
-		fmt.Println("  • Stop menu bar:  launchctl stop com.netmon.menu")
-		fmt.Println("  • Start menu bar: launchctl start com.netmon.menu")
-	}
-	fmt.Println()
-	fmt.Println("The service will automatically start on boot. Enjoy! 🚀")
-}
-
-// setupMenuBarApp sets up the menu bar app as a LaunchAgent
-func setupMenuBarApp(home, menuExePath string) {
-	launchAgentsDir := filepath.Join(home, "Library", "LaunchAgents")
-	plistPath := filepath.Join(launchAgentsDir, "com.netmon.menu.plist")
-
-	// Check if already installed
-	if _, err := os.Stat(plistPath); err == nil {
-		// Unload existing menu bar app
-		fmt.Println("\nUnloading existing menu bar app...")
-		cmd := exec.Command("launchctl", "unload", plistPath)
-		cmd.Run() // Ignore errors, it might not be loaded
-	}
-
-	// Create plist content for menu bar app
-	// Note: Menu bar apps need to run in the user's GUI session
-	// We use LimitLoadToSessionType with Aqua to ensure GUI access
-	plistContent := fmt.Sprintf(`<?xml version="1.0" encoding="UTF-8"?>
-<!DOCTYPE plist PUBLIC "-//Apple//DTD PLIST 1.0//EN" "http://www.apple.com/DTDs/PropertyList-1.0.dtd">
-<plist version="1.0">
-<dict>
-    <key>Label</key>
-    <string>com.netmon.menu</string>
-    
-    <key>ProgramArguments</key>
-    <array>
-        <string>%s</string>
-    </array>
-    
-    <key>RunAtLoad</key>
-    <true/>
-    
-    <key>KeepAlive</key>
-    <true/>
-    
-    <key>LimitLoadToSessionType</key>
-    <string>Aqua</string>
-    
-    <key>StandardOutPath</key>
-    <string>/tmp/netmon-menu.log</string>
-    
-    <key>StandardErrorPath</key>
-    <string>/tmp/netmon-menu.error.log</string>
-    
-    <key>EnvironmentVariables</key>
-    <dict>
-        <key>PATH</key>
-        <string>/usr/local/bin:/usr/bin:/bin:/usr/sbin:/sbin</string>
-    </dict>
-</dict>
-</plist>
-`, menuExePath)
-
-	// Write plist file
-	fmt.Println("\nCreating menu bar app configuration...")
-	if err := os.WriteFile(plistPath, []byte(plistContent), 0644); err != nil {
-		fmt.Fprintf(os.Stderr, "Error: Could not create menu bar plist file: %v\n", err)
-		return
-	}
-	fmt.Printf("✓ Created: %s\n", plistPath)
-
-	// Load the menu bar app
-	fmt.Println("\nLoading and starting menu bar app...")
-	cmd := exec.Command("launchctl", "load", plistPath)
-	output, err := cmd.CombinedOutput()
-	if err != nil {
-		fmt.Fprintf(os.Stderr, "Error loading menu bar app: %v\n%s\n", err, string(output))
-		fmt.Println("⚠️  Menu bar app may need to be started manually after login.")
-		return
-	}
-
-	// Wait a moment for app to start
-	time.Sleep(1 * time.Second)
-
-	// Verify it's running
-	cmd = exec.Command("launchctl", "list", "com.netmon.menu")
-	if err := cmd.Run(); err != nil {
-		fmt.Println("⚠️  Menu bar app loaded but may not be running properly.")
-		fmt.Println("Check logs: tail -f /tmp/netmon-menu.log")
-	} else {
-		fmt.Println("✓ Menu bar app loaded and started successfully!")
-		fmt.Println("  Look for the network usage in your menu bar!")
-	}
-}
-
 // promptYesNo prompts the user for a yes/no answer
 func promptYesNo() bool {
 	reader := bufio.NewReader(os.Stdin)
@@ -0,0 +1,77 @@
+package main
+
+import (
+	"fmt"
+	"netmon/internal/db"
+	"netmon/internal/stats"
+	"os"
+	"sort"
+	"time"
+)
+
+// showStatsNamespaces shows interface traffic inside other network namespaces for a range.
+func showStatsNamespaces(database *db.DB, args []string) {
+	rangeName := "today"
+	if len(args) > 0 {
+		rangeName = args[0]
+	}
+
+	startTime, label, ok := resolveRange(rangeName)
+	if !ok {
+		fmt.Fprintf(os.Stderr, "Unknown range: %s\n", rangeName)
+		printUsage()
+		os.Exit(1)
+	}
+	endTime := time.Now().Unix()
+
+	totals, err := database.GetNetnsTotals(startTime, endTime)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "Error fetching namespace logs: %v\n", err)
+		os.Exit(1)
+	}
+
+	if len(totals) == 0 {
+		fmt.Printf("No namespace data available for %s\n", label)
+		fmt.Println("Start netmon-service with -netns (or -container-netns) to collect it")
+		return
+	}
+
+	namespaces := stats.ComputeByNamespace(totals)
+
+	// Sort by total traffic (descending)
+	sort.Slice(namespaces, func(i, j int) bool {
+		return namespaces[i].TotalBytesIn+namespaces[i].TotalBytesOut > namespaces[j].TotalBytesIn+namespaces[j].TotalBytesOut
+	})
+
+	fmt.Printf("Stats by network namespace (%s)\n", label)
+	fmt.Println()
+	printCoverage(database, startTime, endTime)
+	fmt.Println()
+	fmt.Printf("%-18s %-26s %-12s %-15s %-15s %-15s\n", "Namespace", "Owner", "Interface", "Downloaded", "Uploaded", "Total")
+	fmt.Println("--------------------------------------------------------------------------------------------------------")
+
+	for _, ns := range namespaces {
+		fmt.Printf("%-18s %-26s %-12s %-15s %-15s %-15s\n",
+			ns.Netns,
+			namespaceOwner(ns),
+			ns.Interface,
+			stats.FormatBytes(ns.TotalBytesIn),
+			stats.FormatBytes(ns.TotalBytesOut),
+			stats.FormatBytes(ns.TotalBytesIn+ns.TotalBytesOut))
+	}
+}
+
+// namespaceOwner describes what a namespace belongs to: a container, a named
+// "ip netns" namespace, or else the oldest process running in it.
+func namespaceOwner(ns stats.NamespaceSummary) string {
+	switch {
+	case isContainerID(ns.ContainerID):
+		return "container " + ns.ContainerID[:shortContainerIDLength]
+	case ns.Name != "":
+		return "netns " + ns.Name
+	case ns.Process != "":
+		return ns.Process
+	default:
+		return "-"
+	}
+}
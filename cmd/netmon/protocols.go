@@ -0,0 +1,68 @@
+package main
+
+import (
+	"fmt"
+	"netmon/internal/db"
+	"netmon/internal/stats"
+	"os"
+	"time"
+)
+
+// showStatsProtocols shows traffic totals per protocol/service for a range.
+func showStatsProtocols(database *db.DB, args []string) {
+	rangeName := "today"
+	if len(args) > 0 {
+		rangeName = args[0]
+	}
+
+	startTime, label, ok := resolveRange(rangeName)
+	if !ok {
+		fmt.Fprintf(os.Stderr, "Unknown range: %s\n", rangeName)
+		printUsage()
+		os.Exit(1)
+	}
+	endTime := time.Now().Unix()
+
+	totals, err := database.GetAppGroupTotals(startTime, endTime, db.ColumnProtocol, db.AppLogFilter{})
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "Error fetching app logs: %v\n", err)
+		os.Exit(1)
+	}
+
+	if len(totals) == 0 {
+		fmt.Printf("No application data available for %s\n", label)
+		fmt.Println("Make sure netmon-service is running")
+		return
+	}
+
+	protocols := stats.GroupSummaries(totals, stats.UnclassifiedProtocol)
+
+	sortGroupSummaries(protocols)
+
+	var totalIn, totalOut uint64
+	for _, protocol := range protocols {
+		totalIn += protocol.TotalBytesIn
+		totalOut += protocol.TotalBytesOut
+	}
+
+	fmt.Printf("Stats by protocol (%s)\n", label)
+	fmt.Println()
+	fmt.Println("Overall Totals:")
+	fmt.Printf("  Downloaded: %s\n", stats.FormatBytes(totalIn))
+	fmt.Printf("  Uploaded:   %s\n", stats.FormatBytes(totalOut))
+	fmt.Printf("  Total:      %s\n", stats.FormatBytes(totalIn+totalOut))
+	fmt.Println()
+	printCoverage(database, startTime, endTime)
+	fmt.Println()
+	fmt.Printf("%-20s %-6s %-15s %-15s %-15s\n", "Protocol", "Apps", "Downloaded", "Uploaded", "Total")
+	fmt.Println("--------------------------------------------------------------------------")
+
+	for _, protocol := range protocols {
+		fmt.Printf("%-20s %-6d %-15s %-15s %-15s\n",
+			protocol.Label,
+			protocol.Apps,
+			stats.FormatBytes(protocol.TotalBytesIn),
+			stats.FormatBytes(protocol.TotalBytesOut),
+			stats.FormatBytes(protocol.TotalBytesIn+protocol.TotalBytesOut))
+	}
+}
@@ -0,0 +1,268 @@
+package main
+
+import (
+	"flag"
+	"fmt"
+	"netmon/internal/db"
+	"netmon/internal/stats"
+	"os"
+	"sort"
+	"strings"
+	"time"
+)
+
+// handleQuery breaks the traffic of a range down by any combination of
+// dimensions and metrics.
+func handleQuery(database *db.DB, args []string) {
+	rangeName := "today"
+	if len(args) > 0 && !strings.HasPrefix(args[0], "-") {
+		rangeName, args = args[0], args[1:]
+	}
+
+	fs := flag.NewFlagSet("netmon query", flag.ExitOnError)
+	by := fs.String("by", "", "Comma-separated dimensions: interface, app, hour, day, weekday")
+	metrics := fs.String("metrics", "sum", "Comma-separated metrics: sum, peak, avg, active, percentiles or pNN (e.g. p95)")
+	iface := fs.String("iface", "", "Only count traffic of this interface")
+	app := fs.String("app", "", "Only count traffic of this app (name, part of a name or app key)")
+	user := fs.String("user", "", "Only count traffic of this user (name or UID)")
+	protocol := fs.String("protocol", "", "Only count traffic of this protocol (e.g. HTTPS, DNS, QUIC)")
+	direction := fs.String("direction", "", "Only count downloaded (in) or uploaded (out) traffic")
+	fs.Parse(args)
+
+	startTime, label, ok := resolveRange(rangeName)
+	if !ok {
+		fmt.Fprintf(os.Stderr, "Unknown range: %s\n", rangeName)
+		printUsage()
+		os.Exit(1)
+	}
+
+	q := stats.Query{
+		Start:     startTime,
+		End:       time.Now().Unix(),
+		Interface: *iface,
+		User:      *user,
+		Protocol:  *protocol,
+		Direction: stats.Direction(*direction),
+	}
+
+	var err error
+	if q.GroupBy, err = stats.ParseDimensions(*by); err != nil {
+		fmt.Fprintf(os.Stderr, "Error: %v\n", err)
+		os.Exit(1)
+	}
+	if q.Metrics, q.Percentiles, err = stats.ParseMetrics(*metrics); err != nil {
+		fmt.Fprintf(os.Stderr, "Error: %v\n", err)
+		os.Exit(1)
+	}
+	if *app != "" {
+		match, err := findApp(database, *app)
+		if err != nil {
+			fmt.Fprintf(os.Stderr, "Error: %v\n", err)
+			os.Exit(1)
+		}
+		q.App = match.Key
+	}
+
+	groups, err := stats.Run(database, q)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "Error running query: %v\n", err)
+		os.Exit(1)
+	}
+
+	if len(groups) == 0 {
+		fmt.Printf("No data available for %s\n", label)
+		return
+	}
+
+	if !hasTimeDimension(q.GroupBy) {
+		sortGroupsByTotal(groups)
+	}
+
+	fmt.Printf("Traffic %s(%s)\n", queryHeading(q, *app), label)
+	fmt.Println()
+
+	columns := queryColumns(q)
+	var header, separator strings.Builder
+	for _, d := range q.GroupBy {
+		fmt.Fprintf(&header, "%-*s ", dimensionWidth(d), dimensionTitle(d))
+		separator.WriteString(strings.Repeat("-", dimensionWidth(d)+1))
+	}
+	for _, c := range columns {
+		fmt.Fprintf(&header, "%-14s ", c.title)
+		separator.WriteString(strings.Repeat("-", 15))
+	}
+	fmt.Println(strings.TrimRight(header.String(), " "))
+	fmt.Println(separator.String())
+
+	for _, g := range groups {
+		var line strings.Builder
+		for i, d := range q.GroupBy {
+			fmt.Fprintf(&line, "%-*s ", dimensionWidth(d), truncate(g.Labels[i], dimensionWidth(d)))
+		}
+		for _, c := range columns {
+			fmt.Fprintf(&line, "%-14s ", c.value(g))
+		}
+		fmt.Println(strings.TrimRight(line.String(), " "))
+	}
+}
+
+// queryColumn is a metric column of the query table.
+type queryColumn struct {
+	title string
+	value func(stats.Group) string
+}
+
+// queryColumns returns the metric columns for the query's metrics and direction.
+func queryColumns(q stats.Query) []queryColumn {
+	type flow struct {
+		name string
+		get  func(stats.Group) stats.Flow
+	}
+	var flows []flow
+	if q.Direction != stats.Upload {
+		flows = append(flows, flow{"down", func(g stats.Group) stats.Flow { return g.In }})
+	}
+	if q.Direction != stats.Download {
+		flows = append(flows, flow{"up", func(g stats.Group) stats.Flow { return g.Out }})
+	}
+
+	var columns []queryColumn
+	seen := make(map[stats.Metric]bool)
+	for _, m := range q.Metrics {
+		if seen[m] {
+			continue
+		}
+		seen[m] = true
+
+		switch m {
+		case stats.Sum:
+			titles := map[string]string{"down": "Downloaded", "up": "Uploaded"}
+			for _, f := range flows {
+				f := f
+				columns = append(columns, queryColumn{titles[f.name], func(g stats.Group) string {
+					return stats.FormatBytes(f.get(g).Bytes)
+				}})
+			}
+			if q.Direction == stats.BothDirections {
+				columns = append(columns, queryColumn{"Total", func(g stats.Group) string {
+					return stats.FormatBytes(g.Total())
+				}})
+			}
+		case stats.PeakRate:
+			for _, f := range flows {
+				f := f
+				columns = append(columns, queryColumn{"Peak " + f.name, func(g stats.Group) string {
+					return stats.FormatBytesPerSec(f.get(g).PeakRate)
+				}})
+			}
+		case stats.AvgRate:
+			for _, f := range flows {
+				f := f
+				columns = append(columns, queryColumn{"Avg " + f.name, func(g stats.Group) string {
+					return stats.FormatBytesPerSec(f.get(g).AvgRate)
+				}})
+			}
+		case stats.Percentiles:
+			for i, p := range q.Percentiles {
+				i := i
+				for _, f := range flows {
+					f := f
+					columns = append(columns, queryColumn{fmt.Sprintf("p%g %s", p, f.name), func(g stats.Group) string {
+						return stats.FormatBytesPerSec(f.get(g).Percentiles[i])
+					}})
+				}
+			}
+		case stats.ActiveTime:
+			columns = append(columns, queryColumn{"Active", func(g stats.Group) string {
+				return (time.Duration(g.ActiveSeconds) * time.Second).String()
+			}})
+		}
+	}
+	return columns
+}
+
+// queryHeading describes the breakdown and filters of a query for its
+// heading, e.g. "by app, day for user alice ". appName is the app filter as
+// given.
+func queryHeading(q stats.Query, appName string) string {
+	var heading strings.Builder
+	if len(q.GroupBy) > 0 {
+		names := make([]string, len(q.GroupBy))
+		for i, d := range q.GroupBy {
+			names[i] = string(d)
+		}
+		fmt.Fprintf(&heading, "by %s ", strings.Join(names, ", "))
+	}
+
+	var filters []string
+	if q.Interface != "" {
+		filters = append(filters, "interface "+q.Interface)
+	}
+	if appName != "" {
+		filters = append(filters, "app "+appName)
+	}
+	if q.User != "" {
+		filters = append(filters, "user "+q.User)
+	}
+	if q.Protocol != "" {
+		filters = append(filters, q.Protocol)
+	}
+	switch q.Direction {
+	case stats.Download:
+		filters = append(filters, "downloads")
+	case stats.Upload:
+		filters = append(filters, "uploads")
+	}
+	if len(filters) > 0 {
+		fmt.Fprintf(&heading, "for %s ", strings.Join(filters, ", "))
+	}
+	return heading.String()
+}
+
+// hasTimeDimension reports whether any dimension is a time dimension.
+func hasTimeDimension(dimensions []stats.Dimension) bool {
+	for _, d := range dimensions {
+		if d == stats.ByHour || d == stats.ByDay || d == stats.ByWeekday {
+			return true
+		}
+	}
+	return false
+}
+
+func sortGroupsByTotal(groups []stats.Group) {
+	// Sort by total traffic (descending)
+	sort.Slice(groups, func(i, j int) bool {
+		return groups[i].Total() > groups[j].Total()
+	})
+}
+
+func dimensionTitle(d stats.Dimension) string {
+	switch d {
+	case stats.ByApp:
+		return "Application"
+	default:
+		return strings.ToUpper(string(d[:1])) + string(d[1:])
+	}
+}
+
+func dimensionWidth(d stats.Dimension) int {
+	switch d {
+	case stats.ByApp:
+		return 25
+	case stats.ByHour:
+		return 16
+	case stats.ByDay, stats.ByWeekday:
+		return 10
+	default:
+		return 15
+	}
+}
+
+// truncate shortens s to width characters, marking the cut with "…".
+func truncate(s string, width int) string {
+	runes := []rune(s)
+	if len(runes) <= width {
+		return s
+	}
+	return string(runes[:width-1]) + "…"
+}
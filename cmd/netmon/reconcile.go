@@ -0,0 +1,120 @@
+package main
+
+import (
+	"fmt"
+	"netmon/internal/db"
+	"netmon/internal/stats"
+	"os"
+	"time"
+)
+
+// showStatsReconcile compares interface traffic with the traffic attributed
+// to apps for a range.
+func showStatsReconcile(database *db.DB, args []string) {
+	rangeName := "today"
+	if len(args) > 0 {
+		rangeName = args[0]
+	}
+
+	startTime, label, ok := resolveRange(rangeName)
+	if !ok {
+		fmt.Fprintf(os.Stderr, "Unknown range: %s\n", rangeName)
+		printUsage()
+		os.Exit(1)
+	}
+	endTime := time.Now().Unix()
+
+	totals, err := stats.Run(database, stats.Query{Start: startTime, End: endTime})
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "Error fetching logs: %v\n", err)
+		os.Exit(1)
+	}
+
+	apps, err := stats.Run(database, stats.Query{Start: startTime, End: endTime, GroupBy: []stats.Dimension{stats.ByApp}})
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "Error fetching app logs: %v\n", err)
+		os.Exit(1)
+	}
+
+	if len(totals) == 0 {
+		fmt.Printf("No data available for %s\n", label)
+		fmt.Println("Make sure netmon-service is running")
+		return
+	}
+
+	r := stats.Reconcile(stats.SummaryOf(totals[0]), apps)
+	missingIn, missingOut := r.Missing()
+
+	fmt.Printf("Interface vs. app traffic (%s)\n", label)
+	fmt.Println()
+	printCoverage(database, startTime, endTime)
+	fmt.Println()
+	fmt.Printf("%-20s %-15s %-15s %-15s %-8s\n", "Source", "Downloaded", "Uploaded", "Total", "Share")
+	fmt.Println("--------------------------------------------------------------------------")
+
+	printReconcileRow("Apps", r, r.Attributed)
+	printReconcileRow(db.PermissionDeniedApp, r, r.PermissionDenied)
+	printReconcileRow(db.UnattributedApp, r, r.Unattributed)
+
+	missingShare := 0.0
+	if total := r.Interface.TotalBytesIn + r.Interface.TotalBytesOut; total > 0 {
+		missingShare = float64(missingIn+missingOut) / float64(total) * 100
+	}
+	fmt.Printf("%-20s %-15s %-15s %-15s %6.1f%%\n",
+		"Not in app stats",
+		formatSignedBytes(missingIn),
+		formatSignedBytes(missingOut),
+		formatSignedBytes(missingIn+missingOut),
+		missingShare)
+
+	fmt.Println("--------------------------------------------------------------------------")
+	printReconcileRow("Interfaces", r, r.Interface)
+
+	if missingIn != 0 || missingOut != 0 {
+		// Both logs are written from one read of the interface counters
+		// each tick, so they only differ if app collection or storing
+		// failed for some samples. Each direction is judged on its own, as
+		// a failure in one may hide a failure in the other from the sum.
+		fmt.Println()
+		if missing := directions(missingIn > 0, missingOut > 0); missing != "" {
+			fmt.Printf("⚠️  Some %s interface traffic is missing from app stats: app collection failed\n", missing)
+		}
+		if extra := directions(missingIn < 0, missingOut < 0); extra != "" {
+			fmt.Printf("⚠️  App stats hold more %s traffic than the interface logs: storing interface samples failed\n", extra)
+		}
+		fmt.Println("for part of this range. Check: netmon service status and netmon service logs")
+		fmt.Println("Traffic collected before interface and app logs were written from one read of the")
+		fmt.Println("counters also differs this way without any failure.")
+	}
+}
+
+// directions names the traffic directions that are set, or returns "" if neither is.
+func directions(in, out bool) string {
+	switch {
+	case in && out:
+		return "downloaded and uploaded"
+	case in:
+		return "downloaded"
+	case out:
+		return "uploaded"
+	}
+	return ""
+}
+
+// printReconcileRow prints one source of traffic with its share of the interface total.
+func printReconcileRow(name string, r stats.Reconciliation, s stats.Summary) {
+	fmt.Printf("%-20s %-15s %-15s %-15s %6.1f%%\n",
+		name,
+		stats.FormatBytes(s.TotalBytesIn),
+		stats.FormatBytes(s.TotalBytesOut),
+		stats.FormatBytes(s.TotalBytesIn+s.TotalBytesOut),
+		r.Share(s))
+}
+
+// formatSignedBytes formats a byte difference that may be negative.
+func formatSignedBytes(bytes int64) string {
+	if bytes < 0 {
+		return "-" + stats.FormatBytes(uint64(-bytes))
+	}
+	return stats.FormatBytes(uint64(bytes))
+}
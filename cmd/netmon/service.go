@@ -0,0 +1,181 @@
+package main
+
+import (
+	"flag"
+	"fmt"
+	"netmon/internal/db"
+	"netmon/internal/service"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strconv"
+	"time"
+)
+
+// handleService dispatches the "netmon service" lifecycle subcommands.
+func handleService(database *db.DB, dbPath string, args []string) {
+	if len(args) < 1 {
+		fmt.Fprintln(os.Stderr, "Missing service subcommand")
+		printUsage()
+		os.Exit(1)
+	}
+
+	subcommand := args[0]
+	fs := flag.NewFlagSet("netmon service "+subcommand, flag.ExitOnError)
+	menu := fs.Bool("menu", false, "Act on the menu bar app instead of the collector service")
+	purge := fs.Bool("purge", false, "Also delete the database (uninstall only)")
+	lines := fs.Int("n", 50, "Number of log lines to show before following (logs only)")
+	fs.Parse(args[1:])
+
+	manager, err := service.Detect()
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "Error: %v\n", err)
+		os.Exit(1)
+	}
+
+	spec := service.ServiceSpec(siblingBinary("netmon-service"))
+	if *menu {
+		spec = service.MenuSpec(siblingBinary("netmon-menu"))
+	}
+
+	switch subcommand {
+	case "status":
+		showServiceStatus(manager, database)
+	case "start":
+		runServiceAction("Starting", spec, manager.Start)
+	case "stop":
+		runServiceAction("Stopping", spec, manager.Stop)
+	case "restart":
+		runServiceAction("Restarting", spec, manager.Restart)
+	case "uninstall":
+		uninstallServices(manager, database, dbPath, *purge)
+	case "logs":
+		tailLogs(spec, *lines)
+	default:
+		fmt.Fprintf(os.Stderr, "Unknown service subcommand: %s\n", subcommand)
+		printUsage()
+		os.Exit(1)
+	}
+}
+
+// runServiceAction runs a start/stop/restart action and reports the outcome.
+func runServiceAction(verb string, spec service.Spec, action func(service.Spec) error) {
+	fmt.Printf("%s %s...\n", verb, spec.Label)
+	if err := action(spec); err != nil {
+		fmt.Fprintf(os.Stderr, "Error: %v\n", err)
+		os.Exit(1)
+	}
+	fmt.Println("✓ Done")
+}
+
+// showServiceStatus prints the service manager state and whether the collector is writing.
+func showServiceStatus(manager service.Manager, database *db.DB) {
+	fmt.Printf("Service manager: %s\n", manager.Kind())
+	fmt.Println()
+
+	for _, spec := range []service.Spec{service.ServiceSpec(""), service.MenuSpec("")} {
+		status, err := manager.Status(spec)
+		if err != nil {
+			fmt.Printf("%-20s error: %v\n", spec.Label, err)
+			continue
+		}
+
+		state := "not installed"
+		switch {
+		case status.Running:
+			state = "running (pid " + strconv.Itoa(status.PID) + ")"
+		case status.Installed:
+			state = "stopped"
+		}
+		fmt.Printf("%-20s %s\n", spec.Label, state)
+		if status.Installed {
+			fmt.Printf("%-20s %s\n", "", status.ConfigPath)
+		}
+	}
+	fmt.Println()
+
+	latest, err := database.GetLatestTimestamp()
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "Error fetching last sample: %v\n", err)
+		os.Exit(1)
+	}
+
+	if latest == 0 {
+		fmt.Println("Last sample:     never (no data collected yet)")
+	} else {
+		age := time.Since(time.Unix(latest, 0)).Round(time.Second)
+		fmt.Printf("Last sample:     %s (%s ago)\n", time.Unix(latest, 0).Format("2006-01-02 15:04:05"), age)
+	}
+
+	health, ok, err := database.GetHealth()
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "Error fetching collector health: %v\n", err)
+		os.Exit(1)
+	}
+	if !ok {
+		fmt.Println("Heartbeat:       never")
+		return
+	}
+
+	now := time.Now()
+	fmt.Printf("Heartbeat:       %s ago (pid %d, every %ds)\n",
+		time.Duration(now.Unix()-health.Heartbeat)*time.Second, health.PID, health.IntervalSeconds)
+	if health.LastErrorAt > 0 {
+		fmt.Printf("Last error:      %s at %s\n", health.LastError, time.Unix(health.LastErrorAt, 0).Format("2006-01-02 15:04:05"))
+	}
+	fmt.Printf("Failing ticks:   %d consecutive\n", health.ConsecutiveFailures)
+
+	if warning := health.Warning(now); warning != "" {
+		fmt.Println()
+		fmt.Printf("⚠️  %s\n", warning)
+		fmt.Println("Check logs: netmon service logs")
+	}
+}
+
+// uninstallServices removes the service and menu bar jobs and optionally the database.
+func uninstallServices(manager service.Manager, database *db.DB, dbPath string, purge bool) {
+	for _, spec := range []service.Spec{service.ServiceSpec(""), service.MenuSpec("")} {
+		if err := manager.Uninstall(spec); err != nil {
+			fmt.Fprintf(os.Stderr, "Error uninstalling %s: %v\n", spec.Label, err)
+			os.Exit(1)
+		}
+		fmt.Printf("✓ Removed %s\n", spec.Label)
+	}
+
+	if !purge {
+		fmt.Printf("\nDatabase kept at %s (use --purge to delete it)\n", dbPath)
+		return
+	}
+
+	database.Close()
+	for _, path := range []string{dbPath, dbPath + "-wal", dbPath + "-shm"} {
+		if err := os.Remove(path); err != nil && !os.IsNotExist(err) {
+			fmt.Fprintf(os.Stderr, "Error removing %s: %v\n", path, err)
+			os.Exit(1)
+		}
+	}
+	fmt.Printf("✓ Deleted database %s\n", dbPath)
+}
+
+// tailLogs follows the stdout and stderr logs of a job.
+func tailLogs(spec service.Spec, lines int) {
+	cmd := exec.Command("tail", "-n", strconv.Itoa(lines), "-F", spec.StdoutPath, spec.StderrPath)
+	cmd.Stdout = os.Stdout
+	cmd.Stderr = os.Stderr
+	if err := cmd.Run(); err != nil {
+		fmt.Fprintf(os.Stderr, "Error reading logs: %v\n", err)
+		os.Exit(1)
+	}
+}
+
+// siblingBinary returns the path of another netmon binary installed next to this one.
+func siblingBinary(name string) string {
+	exePath, err := os.Executable()
+	if err != nil {
+		return name
+	}
+	if resolved, err := filepath.EvalSymlinks(exePath); err == nil {
+		exePath = resolved
+	}
+	return filepath.Join(filepath.Dir(exePath), name)
+}
@@ -0,0 +1,314 @@
+package main
+
+import (
+	"flag"
+	"fmt"
+	"netmon/internal/db"
+	"netmon/internal/service"
+	"os"
+	"path/filepath"
+	"time"
+)
+
+// Exit codes returned by "netmon setup" so provisioning tools can tell failures apart.
+const (
+	exitSetupUsage             = 2 // invalid flags (also used by the flag package)
+	exitSetupExecutable        = 3 // could not locate the running executable
+	exitSetupServiceMissing    = 4 // netmon-service not found next to netmon
+	exitSetupMenuMissing       = 5 // --menu requested but netmon-menu not found
+	exitSetupNoServiceManager  = 6 // platform has no supported service manager
+	exitSetupDatabase          = 7 // database could not be created or opened
+	exitSetupServiceInstall    = 8 // writing or loading the service definition failed
+	exitSetupServiceNotRunning = 9 // service installed but not running afterwards
+	exitSetupMenuInstall       = 10
+)
+
+// setupOptions holds the flags accepted by "netmon setup".
+type setupOptions struct {
+	yes      bool
+	service  bool
+	menu     bool
+	dbPath   string
+	interval time.Duration
+	dryRun   bool
+
+	// explicit records which flags were given on the command line
+	explicit map[string]bool
+}
+
+// confirm resolves a yes/no decision from an explicit flag, --yes, or an interactive prompt.
+func (o *setupOptions) confirm(flagName string, value bool, prompt string) bool {
+	if o.explicit[flagName] {
+		return value
+	}
+	if o.yes {
+		return true
+	}
+	fmt.Print(prompt)
+	return promptYesNo()
+}
+
+// handleSetup runs the setup wizard. Every prompt can be answered with flags
+// so it can also run unattended.
+func handleSetup(args []string) {
+	opts := setupOptions{explicit: make(map[string]bool)}
+
+	fs := flag.NewFlagSet("netmon setup", flag.ExitOnError)
+	fs.BoolVar(&opts.yes, "yes", false, "Answer yes to every prompt")
+	fs.BoolVar(&opts.service, "service", true, "Install the background service")
+	fs.BoolVar(&opts.menu, "menu", true, "Install the menu bar app (if netmon-menu is present)")
+	fs.StringVar(&opts.dbPath, "db", getDefaultDBPath(), "Path to SQLite database file used by the service")
+	fs.DurationVar(&opts.interval, "interval", 1*time.Second, "Collection interval for the service")
+	fs.BoolVar(&opts.dryRun, "dry-run", false, "Print the files that would be written without changing anything")
+	fs.Parse(args)
+	fs.Visit(func(f *flag.Flag) { opts.explicit[f.Name] = true })
+
+	if opts.interval < time.Second {
+		fmt.Fprintln(os.Stderr, "Error: --interval must be at least 1s")
+		os.Exit(exitSetupUsage)
+	}
+
+	fmt.Println("╔════════════════════════════════════════════════════════════════╗")
+	fmt.Println("║                                                                ║")
+	fmt.Println("║                    NETMON SETUP WIZARD                         ║")
+	fmt.Println("║                                                                ║")
+	fmt.Println("╚════════════════════════════════════════════════════════════════╝")
+	fmt.Println()
+
+	// Get current executable path
+	exePath, err := os.Executable()
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "Error: Could not determine executable path: %v\n", err)
+		os.Exit(exitSetupExecutable)
+	}
+
+	// Resolve symlinks
+	exePath, err = filepath.EvalSymlinks(exePath)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "Error: Could not resolve executable path: %v\n", err)
+		os.Exit(exitSetupExecutable)
+	}
+
+	// Get the service binary path (assuming it's in the same directory)
+	serviceExePath := filepath.Join(filepath.Dir(exePath), "netmon-service")
+	menuExePath := filepath.Join(filepath.Dir(exePath), "netmon-menu")
+
+	// Check if service binary exists, unless only the menu bar app is wanted
+	if _, err := os.Stat(serviceExePath); err == nil {
+		fmt.Printf("Found netmon-service at: %s\n", serviceExePath)
+	} else if opts.service {
+		fmt.Fprintf(os.Stderr, "Error: netmon-service not found at %s\n", serviceExePath)
+		fmt.Println("\nMake sure both netmon and netmon-service are in the same directory.")
+		os.Exit(exitSetupServiceMissing)
+	}
+
+	// Check if menu bar app exists (optional)
+	menuAppExists := false
+	if _, err := os.Stat(menuExePath); err == nil {
+		menuAppExists = true
+		fmt.Printf("Found netmon-menu at: %s\n", menuExePath)
+	} else if opts.explicit["menu"] && opts.menu {
+		fmt.Fprintf(os.Stderr, "Error: --menu requested but netmon-menu not found at %s\n", menuExePath)
+		os.Exit(exitSetupMenuMissing)
+	}
+	fmt.Println()
+
+	manager, err := service.Detect()
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "Error: %v\n", err)
+		os.Exit(exitSetupNoServiceManager)
+	}
+
+	// Only pass flags that differ from the binaries' defaults
+	var serviceArgs, menuArgs []string
+	if opts.explicit["db"] {
+		absPath, err := filepath.Abs(opts.dbPath)
+		if err != nil {
+			fmt.Fprintf(os.Stderr, "Error: Could not resolve database path: %v\n", err)
+			os.Exit(exitSetupDatabase)
+		}
+		opts.dbPath = absPath
+		serviceArgs = append(serviceArgs, "-db", absPath)
+		menuArgs = append(menuArgs, "-db", absPath)
+	}
+	if opts.explicit["interval"] {
+		serviceArgs = append(serviceArgs, "-interval", opts.interval.String())
+	}
+
+	serviceSpec := service.ServiceSpec(serviceExePath, serviceArgs...)
+	menuSpec := service.MenuSpec(menuExePath, menuArgs...)
+
+	if opts.dryRun {
+		fmt.Println("Dry run: no files will be written.")
+		fmt.Println()
+		if opts.service {
+			printDryRun(manager, serviceSpec)
+		}
+		if menuAppExists && opts.menu {
+			printDryRun(manager, menuSpec)
+		}
+		return
+	}
+
+	// Make sure the database can be created before the service tries to
+	database, err := db.Open(opts.dbPath)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "Error opening database: %v\n", err)
+		os.Exit(exitSetupDatabase)
+	}
+	database.Close()
+
+	// Check if already installed. Declining a reinstall or the service
+	// itself skips to the menu bar app rather than ending setup.
+	installService := true
+	if status, _ := manager.Status(serviceSpec); status.Installed {
+		fmt.Println("⚠️  netmon service is already installed!")
+		fmt.Println()
+
+		if !opts.confirm("service", opts.service, "Do you want to reinstall/update it? (yes/no): ") {
+			fmt.Println("\nKeeping the installed service.")
+			installService = false
+		}
+	}
+
+	if installService {
+		// Ask user if they want persistent service
+		if !opts.explicit["service"] && !opts.yes {
+			fmt.Println("Do you want netmon-service to run automatically in the background?")
+			fmt.Println("This will:")
+			fmt.Println("  ✅ Start monitoring on boot")
+			fmt.Println("  ✅ Keep running after restarts")
+			fmt.Println("  ✅ Track network usage 24/7")
+			fmt.Println()
+		}
+
+		if !opts.confirm("service", opts.service, "Enable background service? (yes/no): ") {
+			fmt.Println("\nBackground service not enabled.")
+			fmt.Println("You can run the service manually with: ./netmon-service")
+			installService = false
+		}
+	}
+
+	if installService {
+		// Write the service configuration and start it
+		fmt.Println("\nCreating service configuration and starting service...")
+		if err := manager.Install(serviceSpec); err != nil {
+			fmt.Fprintf(os.Stderr, "Error installing service: %v\n", err)
+			os.Exit(exitSetupServiceInstall)
+		}
+		fmt.Printf("✓ Created: %s\n", manager.ConfigPath(serviceSpec))
+
+		// Wait a moment for service to start
+		time.Sleep(1 * time.Second)
+
+		// Verify it's running
+		if status, err := manager.Status(serviceSpec); err != nil || !status.Running {
+			fmt.Fprintln(os.Stderr, "⚠️  Service loaded but may not be running properly.")
+			fmt.Fprintln(os.Stderr, "Check logs: netmon service logs")
+			os.Exit(exitSetupServiceNotRunning)
+		}
+		fmt.Println("✓ Service loaded and started successfully!")
+	}
+
+	// Ask about menu bar app
+	menuEnabled := false
+	if menuAppExists {
+		fmt.Println()
+		fmt.Println("━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━")
+		fmt.Println()
+		if !opts.explicit["menu"] && !opts.yes {
+			fmt.Println("Would you like to show network usage in the macOS menu bar?")
+			fmt.Println("This will:")
+			fmt.Println("  ✅ Display today's total network usage in the menu bar")
+			fmt.Println("  ✅ Update every 5 seconds")
+			fmt.Println("  ✅ Show detailed stats on hover")
+			fmt.Println("  ✅ Start automatically on login")
+			fmt.Println()
+		}
+
+		if opts.confirm("menu", opts.menu, "Enable menu bar app? (yes/no): ") {
+			if err := setupMenuBarApp(manager, menuSpec); err != nil {
+				fmt.Fprintf(os.Stderr, "Error installing menu bar app: %v\n", err)
+				fmt.Println("⚠️  Menu bar app may need to be started manually after login.")
+				os.Exit(exitSetupMenuInstall)
+			}
+			menuEnabled = true
+		} else {
+			fmt.Println("\nMenu bar app not enabled.")
+			fmt.Println("You can run it manually with: ./netmon-menu")
+		}
+	}
+
+	if !installService && !menuEnabled {
+		return
+	}
+
+	// Success message
+	fmt.Println()
+	fmt.Println("╔════════════════════════════════════════════════════════════════╗")
+	fmt.Println("║                                                                ║")
+	fmt.Println("║                    ✓ SETUP COMPLETE!                           ║")
+	fmt.Println("║                                                                ║")
+	fmt.Println("╚════════════════════════════════════════════════════════════════╝")
+	fmt.Println()
+	if installService {
+		fmt.Println("netmon-service is now running in the background!")
+	}
+	if menuEnabled {
+		fmt.Println("Menu bar app is configured!")
+	}
+	fmt.Println()
+	fmt.Println("What's next:")
+	fmt.Println("  • View your network usage: netmon")
+	fmt.Println("  • Check service logs:      netmon service logs")
+	fmt.Println("  • View monthly stats:      netmon stats month")
+	if menuEnabled {
+		fmt.Println("  • Menu bar shows:        Today's total network usage")
+	}
+	fmt.Println()
+	fmt.Println("Management commands:")
+	fmt.Println("  • Service status: netmon service status")
+	fmt.Println("  • Stop service:   netmon service stop")
+	fmt.Println("  • Start service:  netmon service start")
+	fmt.Println("  • Uninstall:      netmon service uninstall")
+	if menuEnabled {
+		fmt.Println("  • Stop menu bar:  netmon service stop --menu")
+		fmt.Println("  • Start menu bar: netmon service start --menu")
+	}
+	fmt.Println()
+	if installService {
+		fmt.Println("The service will automatically start on boot. Enjoy! 🚀")
+	}
+}
+
+// printDryRun shows the job definition that setup would write.
+func printDryRun(manager service.Manager, spec service.Spec) {
+	fmt.Printf("Would write %s (%s):\n", manager.ConfigPath(spec), manager.Kind())
+	fmt.Println("----------------------------------------------------------------")
+	fmt.Print(string(manager.Render(spec)))
+	fmt.Println("----------------------------------------------------------------")
+	fmt.Println()
+}
+
+// setupMenuBarApp sets up the menu bar app as a background job
+func setupMenuBarApp(manager service.Manager, menuSpec service.Spec) error {
+	// Write the menu bar app configuration and start it
+	fmt.Println("\nCreating menu bar app configuration and starting it...")
+	if err := manager.Install(menuSpec); err != nil {
+		return err
+	}
+	fmt.Printf("✓ Created: %s\n", manager.ConfigPath(menuSpec))
+
+	// Wait a moment for app to start
+	time.Sleep(1 * time.Second)
+
+	// Verify it's running
+	if status, err := manager.Status(menuSpec); err != nil || !status.Running {
+		fmt.Println("⚠️  Menu bar app loaded but may not be running properly.")
+		fmt.Println("Check logs: netmon service logs --menu")
+	} else {
+		fmt.Println("✓ Menu bar app loaded and started successfully!")
+		fmt.Println("  Look for the network usage in your menu bar!")
+	}
+	return nil
+}
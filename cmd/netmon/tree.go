@@ -0,0 +1,87 @@
+package main
+
+import (
+	"fmt"
+	"netmon/internal/db"
+	"netmon/internal/rules"
+	"netmon/internal/stats"
+	"os"
+	"sort"
+)
+
+// showStatsAppTree shows app traffic rolled up to the apps that spawned it,
+// optionally listing the children of each app underneath it.
+func showStatsAppTree(database *db.DB, ruleSet *rules.Set, startTime, endTime int64, showChildren bool, filter appFilter) {
+	totals, err := database.GetAppParentTotals(startTime, endTime, db.AppLogFilter{User: filter.user, Protocol: filter.protocol})
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "Error fetching app logs: %v\n", err)
+		os.Exit(1)
+	}
+
+	if len(totals) == 0 {
+		fmt.Println("No application data available for today")
+		fmt.Println("Make sure netmon-service is running")
+		return
+	}
+
+	apps, err := database.GetApps()
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "Error fetching apps: %v\n", err)
+		os.Exit(1)
+	}
+
+	nodes := stats.ApplyRulesToTree(stats.ComputeAppTree(totals), apps, ruleSet)
+
+	// Sort by total traffic (descending)
+	sort.Slice(nodes, func(i, j int) bool {
+		return nodes[i].TotalBytesIn+nodes[i].TotalBytesOut > nodes[j].TotalBytesIn+nodes[j].TotalBytesOut
+	})
+
+	var totalIn, totalOut uint64
+	for _, node := range nodes {
+		totalIn += node.TotalBytesIn
+		totalOut += node.TotalBytesOut
+	}
+
+	if showChildren {
+		fmt.Printf("Stats by application tree (%s)\n", appsViewLabel(filter))
+	} else {
+		fmt.Printf("Stats by application, child processes rolled up (%s)\n", appsViewLabel(filter))
+	}
+	fmt.Println()
+	fmt.Println("Overall Totals:")
+	fmt.Printf("  Downloaded: %s\n", stats.FormatBytes(totalIn))
+	fmt.Printf("  Uploaded:   %s\n", stats.FormatBytes(totalOut))
+	fmt.Printf("  Total:      %s\n", stats.FormatBytes(totalIn+totalOut))
+	fmt.Println()
+	printCoverage(database, startTime, endTime)
+	fmt.Println()
+	fmt.Printf("%-30s %-15s %-15s %-15s\n", "Application", "Downloaded", "Uploaded", "Total")
+	fmt.Println("------------------------------------------------------------------------")
+
+	for _, node := range nodes {
+		fmt.Printf("%-30s %-15s %-15s %-15s\n",
+			node.AppName,
+			stats.FormatBytes(node.TotalBytesIn),
+			stats.FormatBytes(node.TotalBytesOut),
+			stats.FormatBytes(node.TotalBytesIn+node.TotalBytesOut))
+
+		if !showChildren {
+			continue
+		}
+
+		sortAppSummaries(node.Children)
+		for i, child := range node.Children {
+			branch := "├─ "
+			if i == len(node.Children)-1 {
+				branch = "└─ "
+			}
+			fmt.Printf("  %s%-25s %-15s %-15s %-15s\n",
+				branch,
+				child.AppName,
+				stats.FormatBytes(child.TotalBytesIn),
+				stats.FormatBytes(child.TotalBytesOut),
+				stats.FormatBytes(child.TotalBytesIn+child.TotalBytesOut))
+		}
+	}
+}
@@ -0,0 +1,77 @@
+package main
+
+import (
+	"fmt"
+	"netmon/internal/db"
+	"netmon/internal/stats"
+	"os"
+	"sort"
+	"time"
+)
+
+// showStatsUsers shows traffic totals per user account for a range.
+func showStatsUsers(database *db.DB, args []string) {
+	rangeName := "today"
+	if len(args) > 0 {
+		rangeName = args[0]
+	}
+
+	startTime, label, ok := resolveRange(rangeName)
+	if !ok {
+		fmt.Fprintf(os.Stderr, "Unknown range: %s\n", rangeName)
+		printUsage()
+		os.Exit(1)
+	}
+	endTime := time.Now().Unix()
+
+	totals, err := database.GetAppGroupTotals(startTime, endTime, db.ColumnUser, db.AppLogFilter{})
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "Error fetching app logs: %v\n", err)
+		os.Exit(1)
+	}
+
+	if len(totals) == 0 {
+		fmt.Printf("No application data available for %s\n", label)
+		fmt.Println("Make sure netmon-service is running")
+		return
+	}
+
+	users := stats.ComputeByUser(totals)
+
+	// Sort by total traffic (descending)
+	sort.Slice(users, func(i, j int) bool {
+		return users[i].TotalBytesIn+users[i].TotalBytesOut > users[j].TotalBytesIn+users[j].TotalBytesOut
+	})
+
+	var totalIn, totalOut uint64
+	for _, user := range users {
+		totalIn += user.TotalBytesIn
+		totalOut += user.TotalBytesOut
+	}
+
+	fmt.Printf("Stats by user (%s)\n", label)
+	fmt.Println()
+	fmt.Println("Overall Totals:")
+	fmt.Printf("  Downloaded: %s\n", stats.FormatBytes(totalIn))
+	fmt.Printf("  Uploaded:   %s\n", stats.FormatBytes(totalOut))
+	fmt.Printf("  Total:      %s\n", stats.FormatBytes(totalIn+totalOut))
+	fmt.Println()
+	printCoverage(database, startTime, endTime)
+	fmt.Println()
+	fmt.Printf("%-20s %-8s %-6s %-15s %-15s %-15s\n", "User", "UID", "Apps", "Downloaded", "Uploaded", "Total")
+	fmt.Println("-----------------------------------------------------------------------------------")
+
+	for _, user := range users {
+		uid := "-"
+		if user.UID >= 0 {
+			uid = fmt.Sprintf("%d", user.UID)
+		}
+		fmt.Printf("%-20s %-8s %-6d %-15s %-15s %-15s\n",
+			user.User,
+			uid,
+			user.Apps,
+			stats.FormatBytes(user.TotalBytesIn),
+			stats.FormatBytes(user.TotalBytesOut),
+			stats.FormatBytes(user.TotalBytesIn+user.TotalBytesOut))
+	}
+}
@@ -3,6 +3,7 @@ module netmon
 go 1.22
 
 require (
+	github.com/getlantern/systray v1.2.2
 	github.com/shirou/gopsutil/v3 v3.24.1
 	modernc.org/sqlite v1.28.0
 )
@@ -15,7 +16,6 @@ require (
 	github.com/getlantern/hex v0.0.0-20190417191902-c6586a6fe0b7 // indirect
 	github.com/getlantern/hidden v0.0.0-20190325191715-f02dbb02be55 // indirect
 	github.com/getlantern/ops v0.0.0-20190325191751-d70cb0d6f85f // indirect
-	github.com/getlantern/systray v1.2.2 // indirect
 	github.com/go-ole/go-ole v1.2.6 // indirect
 	github.com/go-stack/stack v1.8.0 // indirect
 	github.com/google/uuid v1.5.0 // indirect
@@ -2,164 +2,287 @@ package collector
 
 import (
 	"fmt"
+	"netmon/internal/db"
+	"netmon/internal/protocols"
+	"netmon/internal/rules"
+	"netmon/internal/scope"
 )
 
 // AppCollector manages application-level network statistics collection.
 type AppCollector struct {
-	interfaceCollector *Collector
-	connectionMapper   *ConnectionMapper
-	lastTotalBytes     uint64
+	connectionMapper *ConnectionMapper
+	lastTotalBytes   uint64
 }
 
 // NewAppCollector creates a new application network statistics collector.
 func NewAppCollector() *AppCollector {
 	return &AppCollector{
-		interfaceCollector: NewCollector(),
-		connectionMapper:   NewConnectionMapper(),
-		lastTotalBytes:     0,
+		connectionMapper: NewConnectionMapper(),
+		lastTotalBytes:   0,
 	}
 }
 
+// SetRules sets the grouping rules used to name and categorise applications.
+func (ac *AppCollector) SetRules(set *rules.Set) {
+	ac.connectionMapper.SetRules(set)
+}
+
+// SetPorts sets the port map used to split app traffic by protocol.
+func (ac *AppCollector) SetPorts(ports *protocols.Map) {
+	ac.connectionMapper.SetPorts(ports)
+}
+
+// SetScopes sets the classifier used to split app traffic by destination scope.
+func (ac *AppCollector) SetScopes(scopes *scope.Classifier) {
+	ac.connectionMapper.SetScopes(scopes)
+}
+
+// SetQueueSampler enables weighting apps by the data queued on their sockets;
+// see ConnectionMapper.SetQueueSampler.
+func (ac *AppCollector) SetQueueSampler(sampler *QueueSampler) {
+	ac.connectionMapper.SetQueueSampler(sampler)
+}
+
+// SetShortLivedTracker credits traffic to processes that exited between
+// collections; see ConnectionMapper.SetShortLivedTracker.
+func (ac *AppCollector) SetShortLivedTracker(tracker *ShortLivedTracker) {
+	ac.connectionMapper.SetShortLivedTracker(tracker)
+}
+
+// SetRollupDepth enables attributing traffic to ancestor processes; see
+// ConnectionMapper.SetRollupDepth.
+func (ac *AppCollector) SetRollupDepth(depth int) {
+	ac.connectionMapper.SetRollupDepth(depth)
+}
+
 // AppDelta represents the change in network traffic for an application.
 type AppDelta struct {
 	AppName   string
+	Identity  AppIdentity
+	Owner     *AppIdentity // ancestor app that spawned this one, if rolled up
+	Protocol  string       // protocol/service the traffic was attributed to; "" if unclassified
+	Scope     string       // scope of the remote addresses, e.g. scope.WAN; "" if unknown
+	Family    string       // scope.IPv4 or scope.IPv6; "" if unknown
 	BytesIn   uint64
 	BytesOut  uint64
 	Timestamp int64
+	Interval  int64 // seconds the delta covers; see sinceCollection
+
+	// Set by AttributeWithWeighting to show how the traffic was split
+	Weight      float64 // share of the interface traffic, between 0 and 1
+	Connections int     // active connections the share was based on
+	QueuedBytes uint64  // queued bytes sampled on those connections
+	ClosedBytes uint64  // bytes credited exactly from sockets of short-lived processes
 }
 
-// Collect reads current network stats and distributes traffic among active applications.
-// This uses a heuristic approach: traffic is distributed proportionally among apps
-// with active network connections.
-func (ac *AppCollector) Collect() ([]AppDelta, error) {
+// queueWeightUnit is how many sampled queued bytes weigh as much as one
+// active connection.
+const queueWeightUnit = 1024
+
+// AttributeWithWeighting attributes interface deltas collected elsewhere to
+// apps using connection-count weighting, so one read of the interface
+// counters can be stored per interface and split among apps with the same
+// timestamps and totals. Apps with more active connections get
+// proportionally more traffic attributed; with a queue sampler set, data
+// queued on their sockets adds to their weight. Traffic seen while no app
+// had an active connection is credited to the Unattributed pseudo-app.
+// The connection mapping is refreshed even if interfaceDeltas is nil (the
+// first collection), which returns nil.
+func (ac *AppCollector) AttributeWithWeighting(interfaceDeltas []Delta) ([]AppDelta, error) {
 	// Update connection mapping
 	if err := ac.connectionMapper.Update(); err != nil {
 		return nil, fmt.Errorf("update connections: %w", err)
 	}
 
-	// Get interface-level deltas
-	interfaceDeltas, err := ac.interfaceCollector.Collect()
-	if err != nil {
-		return nil, fmt.Errorf("collect interfaces: %w", err)
-	}
-
 	// First collection - no deltas yet
 	if interfaceDeltas == nil {
 		return nil, nil
 	}
 
-	// Get active apps
-	activeApps := ac.connectionMapper.GetActiveApps()
-	if len(activeApps) == 0 {
-		// No active apps detected
-		return []AppDelta{}, nil
-	}
-
-	// Sum total traffic across all interfaces
+	// Sum total traffic
 	var totalBytesIn, totalBytesOut uint64
-	var timestamp int64
+	var timestamp, interval int64
 
 	for _, delta := range interfaceDeltas {
 		totalBytesIn += delta.BytesIn
 		totalBytesOut += delta.BytesOut
 		timestamp = delta.Timestamp
+		interval = delta.Interval
 	}
 
-	// If there's no traffic, return empty
+	// If no traffic, return empty
 	if totalBytesIn == 0 && totalBytesOut == 0 {
 		return []AppDelta{}, nil
 	}
 
-	// Distribute traffic evenly among active apps
-	// Note: This is a heuristic. For accurate per-app tracking, you'd need
-	// a kernel extension or network extension with proper entitlements.
-	bytesInPerApp := totalBytesIn / uint64(len(activeApps))
-	bytesOutPerApp := totalBytesOut / uint64(len(activeApps))
-
-	appDeltas := make([]AppDelta, 0, len(activeApps))
-	for _, appName := range activeApps {
-		appDeltas = append(appDeltas, AppDelta{
-			AppName:   appName,
-			BytesIn:   bytesInPerApp,
-			BytesOut:  bytesOutPerApp,
-			Timestamp: timestamp,
-		})
+	// Get active processes with connection counts, and short-lived ones
+	// that exited since the last collection
+	processes := make([]ProcessNetInfo, 0, len(ac.connectionMapper.lastSnapshot)+len(ac.connectionMapper.exited))
+	for _, procInfo := range ac.connectionMapper.lastSnapshot {
+		processes = append(processes, procInfo)
 	}
+	processes = append(processes, ac.connectionMapper.exited...)
 
-	return appDeltas, nil
-}
+	// Calculate total connections and aggregate by app identity, owner and
+	// connection class
+	appConnections := make(map[string]int)
+	appActivity := make(map[string]uint64)
+	appClosed := make(map[string]ClosedTraffic)
+	appProcs := make(map[string]ProcessNetInfo)
+	appClasses := make(map[string]ConnClass)
+	var keys []string
+	for _, procInfo := range processes {
+		// Processes of one app run by different users or in different
+		// cgroups (containers, systemd units) are kept apart
+		key := fmt.Sprintf("%s\x00%d\x00%s", procInfo.Identity.Key, procInfo.Identity.UID, procInfo.Identity.Cgroup)
+		if procInfo.Owner != nil {
+			key += "\x00" + procInfo.Owner.Key
+		}
+		addClass := func(class ConnClass) string {
+			classKey := fmt.Sprintf("%s\x00%s\x00%s\x00%s", key, class.Protocol, class.Scope, class.Family)
+			if _, ok := appProcs[classKey]; !ok {
+				keys = append(keys, classKey)
+			}
+			appProcs[classKey] = procInfo
+			appClasses[classKey] = class
+			return classKey
+		}
 
-// CollectWithWeighting collects app traffic using connection-count weighting.
-// Apps with more connections get proportionally more traffic attributed.
-func (ac *AppCollector) CollectWithWeighting() ([]AppDelta, error) {
-	// Update connection mapping
-	if err := ac.connectionMapper.Update(); err != nil {
-		return nil, fmt.Errorf("update connections: %w", err)
+		for class, connections := range procInfo.Classes {
+			classKey := addClass(class)
+			appConnections[classKey] += connections
+			appActivity[classKey] += procInfo.Activity[class]
+		}
+		for class, traffic := range procInfo.Closed {
+			classKey := addClass(class)
+			closed := appClosed[classKey]
+			closed.BytesIn += traffic.BytesIn
+			closed.BytesOut += traffic.BytesOut
+			appClosed[classKey] = closed
+		}
 	}
 
-	// Get interface-level deltas
-	interfaceDeltas, err := ac.interfaceCollector.Collect()
-	if err != nil {
-		return nil, fmt.Errorf("collect interfaces: %w", err)
+	weights := make([]float64, len(keys))
+	closedIn := make([]float64, len(keys))
+	closedOut := make([]float64, len(keys))
+	totalWeight := 0.0
+	var totalClosedIn, totalClosedOut uint64
+	for i, key := range keys {
+		weights[i] = float64(appConnections[key]) + float64(appActivity[key])/queueWeightUnit
+		totalWeight += weights[i]
+		closedIn[i] = float64(appClosed[key].BytesIn)
+		closedOut[i] = float64(appClosed[key].BytesOut)
+		totalClosedIn += appClosed[key].BytesIn
+		totalClosedOut += appClosed[key].BytesOut
 	}
 
-	// First collection - no deltas yet
-	if interfaceDeltas == nil {
-		return nil, nil
+	if totalWeight == 0 && totalClosedIn == 0 && totalClosedOut == 0 {
+		// No active connections to attribute the traffic to
+		return unattributed(totalBytesIn, totalBytesOut, timestamp, interval), nil
 	}
 
-	// Get active processes with connection counts
-	snapshot := ac.connectionMapper.lastSnapshot
-	if len(snapshot) == 0 {
-		return []AppDelta{}, nil
-	}
+	// Sockets closed by short-lived processes come with exact byte counts,
+	// already cut down to this collection's share, which are credited first
+	// (as far as the interfaces saw that much)
+	creditedIn := min(totalClosedIn, totalBytesIn)
+	creditedOut := min(totalClosedOut, totalBytesOut)
+	exactIn := splitBytes(creditedIn, closedIn)
+	exactOut := splitBytes(creditedOut, closedOut)
 
-	// Sum total traffic and total connections
-	var totalBytesIn, totalBytesOut uint64
-	var timestamp int64
+	// Distribute the rest proportionally based on weight
+	remainingIn := totalBytesIn - creditedIn
+	remainingOut := totalBytesOut - creditedOut
+	bytesIn := splitBytes(remainingIn, weights)
+	bytesOut := splitBytes(remainingOut, weights)
+	appDeltas := make([]AppDelta, 0, len(keys)+1)
 
-	for _, delta := range interfaceDeltas {
-		totalBytesIn += delta.BytesIn
-		totalBytesOut += delta.BytesOut
-		timestamp = delta.Timestamp
-	}
+	for i, key := range keys {
+		share := 0.0
+		if totalWeight > 0 {
+			share = weights[i] / totalWeight
+		}
 
-	// If no traffic, return empty
-	if totalBytesIn == 0 && totalBytesOut == 0 {
-		return []AppDelta{}, nil
-	}
+		appDeltas = append(appDeltas, AppDelta{
+			AppName:   appProcs[key].Identity.Name,
+			Identity:  appProcs[key].Identity,
+			Owner:     appProcs[key].Owner,
+			Protocol:  appClasses[key].Protocol,
+			Scope:     appClasses[key].Scope,
+			Family:    appClasses[key].Family,
+			BytesIn:   exactIn[i] + bytesIn[i],
+			BytesOut:  exactOut[i] + bytesOut[i],
+			Timestamp: timestamp,
+			Interval:  interval,
 
-	// Calculate total connections and aggregate by app
-	appConnections := make(map[string]int)
-	for _, procInfo := range snapshot {
-		appConnections[procInfo.AppName] += procInfo.Connections
+			Weight:      share,
+			Connections: appConnections[key],
+			QueuedBytes: appActivity[key],
+			ClosedBytes: exactIn[i] + exactOut[i],
+		})
 	}
 
-	totalConnections := 0
-	for _, count := range appConnections {
-		totalConnections += count
+	// Only short-lived processes used the network, and they did not explain
+	// all of the traffic
+	if totalWeight == 0 && (remainingIn > 0 || remainingOut > 0) {
+		appDeltas = append(appDeltas, unattributed(remainingIn, remainingOut, timestamp, interval)...)
 	}
 
-	if totalConnections == 0 {
-		return []AppDelta{}, nil
+	return appDeltas, nil
+}
+
+// unattributedIdentity is the identity traffic is credited to when no
+// process had an active connection to explain it.
+var unattributedIdentity = AppIdentity{
+	Key:  db.UnattributedAppKey,
+	Name: db.UnattributedApp,
+	UID:  -1,
+}
+
+// unattributed credits all traffic of a collection to the Unattributed pseudo-app.
+func unattributed(bytesIn, bytesOut uint64, timestamp, interval int64) []AppDelta {
+	return []AppDelta{{
+		AppName:   unattributedIdentity.Name,
+		Identity:  unattributedIdentity,
+		BytesIn:   bytesIn,
+		BytesOut:  bytesOut,
+		Timestamp: timestamp,
+		Interval:  interval,
+		Weight:    1,
+	}}
+}
+
+// splitBytes splits total in proportion to weights. Each part is the share
+// of total up to and including its weight minus the share before it, so the
+// parts always add up to total exactly and rounding loses no bytes.
+func splitBytes(total uint64, weights []float64) []uint64 {
+	var sum float64
+	for _, weight := range weights {
+		sum += weight
 	}
 
-	// Distribute traffic proportionally based on connection count
-	appDeltas := make([]AppDelta, 0, len(appConnections))
+	parts := make([]uint64, len(weights))
+	if sum == 0 {
+		return parts // nothing to split by; the caller accounts for total itself
+	}
+	var cumulative float64
+	var assigned uint64
+	for i, weight := range weights {
+		cumulative += weight
 
-	for appName, connections := range appConnections {
-		weight := float64(connections) / float64(totalConnections)
-		bytesIn := uint64(float64(totalBytesIn) * weight)
-		bytesOut := uint64(float64(totalBytesOut) * weight)
+		end := total
+		if i < len(weights)-1 {
+			end = uint64(float64(total) * (cumulative / sum))
+			if end > total {
+				end = total
+			}
+		}
+		if end < assigned {
+			end = assigned
+		}
 
-		appDeltas = append(appDeltas, AppDelta{
-			AppName:   appName,
-			BytesIn:   bytesIn,
-			BytesOut:  bytesOut,
-			Timestamp: timestamp,
-		})
+		parts[i] = end - assigned
+		assigned = end
 	}
 
-	return appDeltas, nil
+	return parts
 }
-
@@ -0,0 +1,65 @@
+package collector
+
+import (
+	"math"
+	"reflect"
+	"testing"
+)
+
+func TestSplitBytes(t *testing.T) {
+	tests := []struct {
+		name    string
+		total   uint64
+		weights []float64
+		want    []uint64 // nil to only check the sum
+	}{
+		{name: "even split", total: 9, weights: []float64{1, 1, 1}, want: []uint64{3, 3, 3}},
+		{name: "rounding goes to the last part", total: 10, weights: []float64{1, 1, 1}, want: []uint64{3, 3, 4}},
+		{name: "single byte", total: 1, weights: []float64{1, 1, 1}, want: []uint64{0, 0, 1}},
+		{name: "proportional", total: 1000, weights: []float64{3, 1}, want: []uint64{750, 250}},
+		{name: "zero weight gets nothing", total: 1000, weights: []float64{1, 0, 1}, want: []uint64{500, 0, 500}},
+		{name: "single weight", total: 12345, weights: []float64{0.2}, want: []uint64{12345}},
+		{name: "no traffic", total: 0, weights: []float64{2, 5}, want: []uint64{0, 0}},
+		{name: "nothing to split by", total: 1000, weights: []float64{0, 0}, want: []uint64{0, 0}},
+		{name: "fractional weights", total: 1<<40 + 7, weights: []float64{0.1, 0.7, 0.2, 1e-9}},
+		{name: "total beyond float precision", total: math.MaxUint64, weights: []float64{1, 1, 1}},
+		{name: "many parts", total: 999983, weights: manyWeights(1000)},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			parts := splitBytes(tt.total, tt.weights)
+			if len(parts) != len(tt.weights) {
+				t.Fatalf("got %d parts, want %d", len(parts), len(tt.weights))
+			}
+			if tt.want != nil && !reflect.DeepEqual(parts, tt.want) {
+				t.Errorf("splitBytes(%d, %v) = %v, want %v", tt.total, tt.weights, parts, tt.want)
+			}
+
+			var weightSum float64
+			for _, weight := range tt.weights {
+				weightSum += weight
+			}
+			if weightSum == 0 {
+				return
+			}
+
+			var sum uint64
+			for _, part := range parts {
+				sum += part
+			}
+			if sum != tt.total {
+				t.Errorf("parts add up to %d, want the total %d", sum, tt.total)
+			}
+		})
+	}
+}
+
+// manyWeights returns n uneven weights.
+func manyWeights(n int) []float64 {
+	weights := make([]float64, n)
+	for i := range weights {
+		weights[i] = float64(i%7) + 0.3
+	}
+	return weights
+}
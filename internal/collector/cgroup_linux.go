@@ -0,0 +1,50 @@
+package collector
+
+import (
+	"fmt"
+	"os"
+	"regexp"
+	"strings"
+)
+
+// containerIDPattern matches the 64-hex-digit IDs Docker, podman and
+// containerd embed in cgroup paths (e.g. docker-<id>.scope, /docker/<id>).
+var containerIDPattern = regexp.MustCompile(`[0-9a-f]{64}`)
+
+// readCgroup returns the cgroup path of a process along with the systemd unit
+// and container ID derived from it.
+func readCgroup(pid int32) (path, unit, containerID string) {
+	data, err := os.ReadFile(fmt.Sprintf("/proc/%d/cgroup", pid))
+	if err != nil {
+		return "", "", ""
+	}
+
+	for _, line := range strings.Split(strings.TrimSpace(string(data)), "\n") {
+		// Format is hierarchy-ID:controllers:path. Prefer the unified (v2)
+		// hierarchy "0::", falling back to the systemd v1 hierarchy.
+		parts := strings.SplitN(line, ":", 3)
+		if len(parts) != 3 {
+			continue
+		}
+		if parts[0] == "0" && parts[1] == "" {
+			path = parts[2]
+			break
+		}
+		if parts[1] == "name=systemd" {
+			path = parts[2]
+		}
+	}
+
+	return path, systemdUnit(path), containerIDPattern.FindString(path)
+}
+
+// systemdUnit returns the innermost .service or .scope unit in a cgroup path.
+func systemdUnit(cgroupPath string) string {
+	segments := strings.Split(cgroupPath, "/")
+	for i := len(segments) - 1; i >= 0; i-- {
+		if strings.HasSuffix(segments[i], ".service") || strings.HasSuffix(segments[i], ".scope") {
+			return segments[i]
+		}
+	}
+	return ""
+}
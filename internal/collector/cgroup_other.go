@@ -0,0 +1,8 @@
+//go:build !linux
+
+package collector
+
+// readCgroup is a no-op on platforms without cgroups.
+func readCgroup(pid int32) (path, unit, containerID string) {
+	return "", "", ""
+}
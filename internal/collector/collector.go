@@ -2,12 +2,25 @@ package collector
 
 import (
 	"fmt"
+	"math"
 	"time"
 )
 
+// suspendThreshold is how far wall-clock time may run ahead of monotonic time
+// between two collections before we assume the system was asleep.
+const suspendThreshold = 2 * time.Second
+
 // Collector manages network statistics collection and delta computation.
 type Collector struct {
-	lastStats map[string]InterfaceStats
+	lastStats   map[string]InterfaceStats
+	lastTime    time.Time
+	lastSuspend *Suspend
+}
+
+// Suspend describes a period in which the system was asleep between two collections.
+type Suspend struct {
+	Start int64 // estimated wall-clock time the system went to sleep
+	End   int64 // wall-clock time of the first collection after waking
 }
 
 // NewCollector creates a new network statistics collector.
@@ -23,6 +36,32 @@ type Delta struct {
 	BytesIn   uint64
 	BytesOut  uint64
 	Timestamp int64
+	Interval  int64 // seconds the delta covers; see sinceCollection
+}
+
+// sinceCollection returns the time between two collections and whether the
+// system slept in between. Monotonic time stops while the system sleeps but
+// wall-clock time does not, so the difference between the two reveals a
+// suspend. Counters keep whatever moved while asleep (e.g. during dark wakes),
+// so after a suspend the wall-clock time is returned: the first delta after
+// waking is spread over the whole sleep, keeping its rate an average rather
+// than a one-second spike.
+func sinceCollection(last, now time.Time) (time.Duration, bool) {
+	awake := now.Sub(last)
+	wall := now.Round(0).Sub(last.Round(0))
+	if wall-awake > suspendThreshold {
+		return wall, true
+	}
+	return awake, false
+}
+
+// intervalSeconds rounds the time a delta covers to whole seconds, at least 1.
+func intervalSeconds(elapsed time.Duration) int64 {
+	interval := int64(math.Round(elapsed.Seconds()))
+	if interval < 1 {
+		interval = 1
+	}
+	return interval
 }
 
 // Collect reads current interface stats and computes deltas since last collection.
@@ -33,6 +72,11 @@ func (c *Collector) Collect() ([]Delta, error) {
 		return nil, fmt.Errorf("read interfaces: %w", err)
 	}
 
+	nowTime := time.Now()
+	lastTime := c.lastTime
+	c.lastTime = nowTime
+	c.lastSuspend = nil
+
 	// First collection - just store state
 	if len(c.lastStats) == 0 {
 		for _, stat := range currentStats {
@@ -41,8 +85,17 @@ func (c *Collector) Collect() ([]Delta, error) {
 		return nil, nil
 	}
 
+	elapsed, suspended := sinceCollection(lastTime, nowTime)
+	if suspended {
+		c.lastSuspend = &Suspend{
+			Start: lastTime.Add(nowTime.Sub(lastTime)).Unix(), // after the awake part
+			End:   nowTime.Unix(),
+		}
+	}
+	interval := intervalSeconds(elapsed)
+
 	// Compute deltas
-	now := time.Now().Unix()
+	now := nowTime.Unix()
 	var deltas []Delta
 
 	for _, current := range currentStats {
@@ -75,6 +128,7 @@ func (c *Collector) Collect() ([]Delta, error) {
 			BytesIn:   deltaIn,
 			BytesOut:  deltaOut,
 			Timestamp: now,
+			Interval:  interval,
 		})
 
 		// Update state
@@ -84,3 +138,11 @@ func (c *Collector) Collect() ([]Delta, error) {
 	return deltas, nil
 }
 
+
+// LastSuspend returns the suspend detected by the most recent Collect call, if any.
+func (c *Collector) LastSuspend() (Suspend, bool) {
+	if c.lastSuspend == nil {
+		return Suspend{}, false
+	}
+	return *c.lastSuspend, true
+}
@@ -0,0 +1,118 @@
+package collector
+
+import (
+	"netmon/internal/rules"
+	"os"
+	"path/filepath"
+	"regexp"
+	"strings"
+
+	"github.com/shirou/gopsutil/v3/process"
+)
+
+// AppIdentity describes the program behind a process with network activity.
+type AppIdentity struct {
+	Key         string // stable grouping key, e.g. "bundle:com.google.Chrome" or "exe:/usr/bin/curl"
+	Name        string // user-friendly display name
+	ExePath     string
+	BundleID    string // macOS bundle identifier, if the process lives in a .app bundle
+	Cmdline     string
+	UID         int32 // -1 if unknown
+	Username    string
+	PPID        int32
+	Cgroup      string // Linux cgroup path
+	SystemdUnit string // Linux systemd unit owning the cgroup
+	ContainerID string // Docker/podman container ID, if any
+	Owner       bool   // rules mark this app as owning its child processes' traffic
+}
+
+// resolveIdentity gathers identity details for a process. Fields that cannot
+// be read (e.g. for processes owned by other users) are left empty.
+func resolveIdentity(proc *process.Process, processName string) AppIdentity {
+	id := AppIdentity{
+		Name: cleanProcessName(processName),
+		UID:  -1,
+	}
+
+	if exe, err := proc.Exe(); err == nil {
+		id.ExePath = exe
+	}
+	if cmdline, err := proc.Cmdline(); err == nil {
+		id.Cmdline = cmdline
+	}
+	if uids, err := proc.Uids(); err == nil && len(uids) > 0 {
+		id.UID = uids[0]
+	}
+	if username, err := proc.Username(); err == nil {
+		id.Username = username
+	}
+	if ppid, err := proc.Ppid(); err == nil {
+		id.PPID = ppid
+	}
+
+	id.Cgroup, id.SystemdUnit, id.ContainerID = readCgroup(proc.Pid)
+
+	// On macOS, apps are in .app bundles like:
+	// /Applications/Google Chrome.app/Contents/MacOS/Google Chrome
+	if bundlePath, ok := appBundlePath(id.ExePath); ok {
+		id.Name = strings.TrimSuffix(filepath.Base(bundlePath), ".app")
+		id.BundleID = readBundleID(bundlePath)
+		if id.BundleID != "" {
+			id.Key = "bundle:" + id.BundleID
+		} else {
+			id.Key = "bundle:" + bundlePath
+		}
+		return id
+	}
+
+	if id.ExePath != "" {
+		id.Key = "exe:" + id.ExePath
+	} else {
+		id.Key = "name:" + processName
+	}
+
+	return id
+}
+
+// appBundlePath returns the outermost .app bundle containing exe, so helpers
+// nested inside an app (e.g. Chrome's renderer) resolve to the app itself.
+func appBundlePath(exe string) (string, bool) {
+	idx := strings.Index(exe, ".app/")
+	if idx < 0 {
+		return "", false
+	}
+	return exe[:idx+len(".app")], true
+}
+
+var bundleIDPattern = regexp.MustCompile(`<key>CFBundleIdentifier</key>\s*<string>([^<]+)</string>`)
+
+// readBundleID reads CFBundleIdentifier from an XML Info.plist. Binary plists
+// are not supported and yield "".
+func readBundleID(bundlePath string) string {
+	data, err := os.ReadFile(filepath.Join(bundlePath, "Contents", "Info.plist"))
+	if err != nil {
+		return ""
+	}
+	if m := bundleIDPattern.FindSubmatch(data); m != nil {
+		return strings.TrimSpace(string(m[1]))
+	}
+	return ""
+}
+
+// applyRules renames an identity and marks owners according to a rule set.
+// Categories are left to the stats, which apply the current rules.
+// Renamed apps are grouped under a rule key so all matching processes merge.
+func applyRules(identity AppIdentity, set *rules.Set) AppIdentity {
+	result := set.Match(rules.Subject{
+		Name:    identity.Name,
+		ExePath: identity.ExePath,
+		Cmdline: identity.Cmdline,
+	})
+
+	if result.App != "" {
+		identity.Name = result.App
+		identity.Key = "rule:" + result.App
+	}
+	identity.Owner = result.Owner
+	return identity
+}
@@ -0,0 +1,45 @@
+package collector
+
+// SeenProcess is a process the last update saw using the network.
+type SeenProcess struct {
+	ProcessKey
+	Name     string
+	Identity AppIdentity
+	Exited   bool // a short-lived process that exited before the update
+}
+
+// GetProcesses returns the processes seen by the last update, including
+// short-lived ones that have already exited. Processes whose start time
+// could not be read are left out, since they can't be told apart from
+// others with the same PID.
+func (cm *ConnectionMapper) GetProcesses() []SeenProcess {
+	processes := make([]SeenProcess, 0, len(cm.lastSnapshot)+len(cm.exited))
+
+	for key, info := range cm.lastSnapshot {
+		if key.PID == permissionDeniedPID || key.StartTime == 0 {
+			continue
+		}
+		processes = append(processes, SeenProcess{ProcessKey: key, Name: info.ProcessName, Identity: info.Identity})
+	}
+
+	for _, info := range cm.exited {
+		if info.StartTime == 0 {
+			continue
+		}
+		processes = append(processes, SeenProcess{ProcessKey: info.Key(), Name: info.ProcessName, Identity: info.Identity, Exited: true})
+	}
+
+	return processes
+}
+
+// GetProcesses returns the processes seen by the last collection.
+func (ac *AppCollector) GetProcesses() []SeenProcess {
+	return ac.connectionMapper.GetProcesses()
+}
+
+// ProcessAlive reports whether the process identified by key is still
+// running. A different process reusing the PID does not count.
+func ProcessAlive(key ProcessKey) bool {
+	startTime, ok := processStartTime(key.PID)
+	return ok && startTime == key.StartTime
+}
@@ -0,0 +1,91 @@
+package collector
+
+import (
+	"fmt"
+	"net"
+
+	psnet "github.com/shirou/gopsutil/v3/net"
+)
+
+// Interface types reported in LinkInfo.Type.
+const (
+	LinkEthernet = "ethernet"
+	LinkWireless = "wireless"
+	LinkLoopback = "loopback"
+	LinkBridge   = "bridge"
+	LinkVLAN     = "vlan"
+	LinkTunnel   = "tunnel"  // tun devices, WireGuard, IP-in-IP and the like
+	LinkVirtual  = "virtual" // software Ethernet devices: veth pairs, dummies, ...
+)
+
+// LinkInfo describes a network interface of this host as it is now.
+type LinkInfo struct {
+	Name      string
+	Type      string // one of the Link* types; "" if unknown
+	Up        bool
+	MAC       string
+	MTU       int
+	Addresses []string // in CIDR notation
+	SpeedMbps int64    // negotiated link speed; 0 if unknown (wireless and virtual links report none)
+
+	// Counters since the interface came up
+	ErrorsIn  uint64
+	ErrorsOut uint64
+	DropsIn   uint64
+	DropsOut  uint64
+}
+
+// ReadLinkInfo returns the details of the named interface. The type and link
+// speed are only known on Linux, where they are read from /sys/class/net.
+func ReadLinkInfo(name string) (LinkInfo, error) {
+	iface, err := net.InterfaceByName(name)
+	if err != nil {
+		return LinkInfo{}, err
+	}
+
+	info := LinkInfo{
+		Name: iface.Name,
+		Up:   iface.Flags&net.FlagUp != 0,
+		MAC:  iface.HardwareAddr.String(),
+		MTU:  iface.MTU,
+	}
+	if iface.Flags&net.FlagLoopback != 0 {
+		info.Type = LinkLoopback
+	}
+
+	addrs, err := iface.Addrs()
+	if err != nil {
+		return LinkInfo{}, fmt.Errorf("read addresses: %w", err)
+	}
+	for _, addr := range addrs {
+		info.Addresses = append(info.Addresses, addr.String())
+	}
+
+	counters, err := psnet.IOCounters(true)
+	if err != nil {
+		return LinkInfo{}, fmt.Errorf("read io counters: %w", err)
+	}
+	for _, counter := range counters {
+		if counter.Name == name {
+			info.ErrorsIn, info.ErrorsOut = counter.Errin, counter.Errout
+			info.DropsIn, info.DropsOut = counter.Dropin, counter.Dropout
+			break
+		}
+	}
+
+	readLinkDetails(&info)
+	return info, nil
+}
+
+// InterfaceNames returns the names of the host's network interfaces.
+func InterfaceNames() ([]string, error) {
+	ifaces, err := net.Interfaces()
+	if err != nil {
+		return nil, err
+	}
+	names := make([]string, len(ifaces))
+	for i, iface := range ifaces {
+		names[i] = iface.Name
+	}
+	return names, nil
+}
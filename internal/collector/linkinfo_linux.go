@@ -0,0 +1,86 @@
+package collector
+
+import (
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+)
+
+// ARPHRD_* hardware types found in /sys/class/net/<name>/type.
+const (
+	arphrdEther    = 1
+	arphrdTunnel   = 768
+	arphrdTunnel6  = 769
+	arphrdSit      = 776
+	arphrdLoopback = 772
+	arphrdNone     = 65534 // tun devices, WireGuard
+)
+
+// readLinkDetails fills in the type and link speed of an interface from
+// /sys/class/net.
+func readLinkDetails(info *LinkInfo) {
+	dir := filepath.Join("/sys/class/net", info.Name)
+	info.Type = linkType(dir)
+
+	// Links without a negotiated speed (down, wireless, virtual) report -1
+	// or fail to read
+	if speed, err := readSysInt(filepath.Join(dir, "speed")); err == nil && speed > 0 {
+		info.SpeedMbps = speed
+	}
+}
+
+// linkType classifies an interface by its hardware type, its uevent DEVTYPE
+// and whether it is backed by a device.
+func linkType(dir string) string {
+	if exists(filepath.Join(dir, "wireless")) || exists(filepath.Join(dir, "phy80211")) {
+		return LinkWireless
+	}
+
+	if uevent, err := os.ReadFile(filepath.Join(dir, "uevent")); err == nil {
+		for _, line := range strings.Split(string(uevent), "\n") {
+			switch strings.TrimPrefix(line, "DEVTYPE=") {
+			case "bridge":
+				return LinkBridge
+			case "vlan":
+				return LinkVLAN
+			case "wlan":
+				return LinkWireless
+			case "wireguard":
+				return LinkTunnel
+			}
+		}
+	}
+
+	hwType, err := readSysInt(filepath.Join(dir, "type"))
+	if err != nil {
+		return ""
+	}
+	switch hwType {
+	case arphrdLoopback:
+		return LinkLoopback
+	case arphrdEther:
+		if exists(filepath.Join(dir, "device")) {
+			return LinkEthernet
+		}
+		return LinkVirtual
+	case arphrdTunnel, arphrdTunnel6, arphrdSit, arphrdNone:
+		return LinkTunnel
+	default:
+		return ""
+	}
+}
+
+// readSysInt reads a sysfs attribute holding one integer.
+func readSysInt(path string) (int64, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return 0, err
+	}
+	return strconv.ParseInt(strings.TrimSpace(string(data)), 10, 64)
+}
+
+func exists(path string) bool {
+	_, err := os.Stat(path)
+	return err == nil
+}
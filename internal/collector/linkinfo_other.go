@@ -0,0 +1,7 @@
+//go:build !linux
+
+package collector
+
+// readLinkDetails is a no-op where link details are not exposed in
+// /sys/class/net; only loopback interfaces get a type.
+func readLinkDetails(info *LinkInfo) {}
@@ -0,0 +1,85 @@
+package collector
+
+import (
+	"syscall"
+
+	"github.com/shirou/gopsutil/v3/net"
+)
+
+// listenStatus is the connection status gopsutil reports for listening TCP
+// sockets. UDP sockets have no states and are reported as "NONE".
+const listenStatus = "LISTEN"
+
+// isListener reports whether a socket accepts traffic from any peer: a TCP
+// socket in LISTEN state or an unconnected UDP socket bound to a port.
+func isListener(conn net.ConnectionStat) bool {
+	if conn.Type == syscall.SOCK_DGRAM {
+		return conn.Raddr.Port == 0 && conn.Laddr.Port != 0
+	}
+	return conn.Status == listenStatus
+}
+
+// listenPort is a local port a socket listens on.
+type listenPort struct {
+	transport string
+	port      uint32
+}
+
+// listeningPorts returns the ports listened on by any of connections.
+// Connections on those ports were accepted by a local server.
+func listeningPorts(connections []net.ConnectionStat) map[listenPort]bool {
+	ports := make(map[listenPort]bool)
+	for _, conn := range connections {
+		if isListener(conn) {
+			ports[listenPort{transportName(conn.Type), conn.Laddr.Port}] = true
+		}
+	}
+	return ports
+}
+
+// Listener is a socket a process accepts connections on.
+type Listener struct {
+	Transport string // "tcp" or "udp"
+	Address   string // local address, e.g. "0.0.0.0" or "::1"
+	Port      uint32
+}
+
+// ActiveListener is a listening socket together with the app that owns it.
+type ActiveListener struct {
+	Listener
+	PID      int32
+	Identity AppIdentity
+}
+
+// GetListeners returns the listening sockets found by the last update. A
+// socket shared by several processes (e.g. pre-forked workers) is listed once.
+func (cm *ConnectionMapper) GetListeners() []ActiveListener {
+	type listenerKey struct {
+		app string
+		Listener
+	}
+	seen := make(map[listenerKey]bool)
+	var listeners []ActiveListener
+
+	for _, info := range cm.lastSnapshot {
+		for _, listener := range info.Listeners {
+			key := listenerKey{info.Identity.Key, listener}
+			if seen[key] {
+				continue
+			}
+			seen[key] = true
+			listeners = append(listeners, ActiveListener{
+				Listener: listener,
+				PID:      info.PID,
+				Identity: info.Identity,
+			})
+		}
+	}
+
+	return listeners
+}
+
+// GetListeners returns the listening sockets found by the last collection.
+func (ac *AppCollector) GetListeners() []ActiveListener {
+	return ac.connectionMapper.GetListeners()
+}
@@ -0,0 +1,159 @@
+package collector
+
+import (
+	"bufio"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"syscall"
+)
+
+// netnsRunDir is where "ip netns add" bind-mounts named namespaces.
+const netnsRunDir = "/run/netns"
+
+// readNamespaces scans /proc for processes living in a network namespace
+// other than our own and reads the interface counters of each namespace once,
+// through the oldest process found in it. Unless all is set, only namespaces
+// of container processes are read. owners caches the namespace and container
+// of every process, keyed with its start time so a reused PID is looked up
+// afresh, and is pruned of exited processes.
+func readNamespaces(owners map[ProcessKey]netnsOwner, all bool) ([]namespaceStats, error) {
+	hostNetns, err := os.Readlink("/proc/self/ns/net")
+	if err != nil {
+		return nil, fmt.Errorf("read own network namespace: %w", err)
+	}
+
+	entries, err := os.ReadDir("/proc")
+	if err != nil {
+		return nil, fmt.Errorf("read /proc: %w", err)
+	}
+
+	seen := make(map[ProcessKey]bool, len(entries))
+	pidByNetns := make(map[string]int32)
+	var order []netnsOwner
+
+	for _, entry := range entries {
+		pid64, err := strconv.ParseInt(entry.Name(), 10, 32)
+		if err != nil {
+			continue
+		}
+		pid := int32(pid64)
+		key := ProcessKey{PID: pid, StartTime: readStartTime(pid)}
+		seen[key] = true
+
+		owner, cached := owners[key]
+		if !cached {
+			// Reading another user's namespace link needs privileges; such
+			// processes are cached as unknown rather than retried every tick.
+			owner.netns, _ = os.Readlink(fmt.Sprintf("/proc/%d/ns/net", pid))
+			_, _, owner.containerID = readCgroup(pid)
+			owners[key] = owner
+		}
+
+		if owner.netns == "" || owner.netns == hostNetns || (!all && owner.containerID == "") {
+			continue
+		}
+
+		// /proc is listed in lexical order, so compare PIDs to find the oldest
+		first, exists := pidByNetns[owner.netns]
+		if !exists {
+			order = append(order, owner)
+		}
+		if !exists || pid < first {
+			pidByNetns[owner.netns] = pid
+		}
+	}
+
+	for key := range owners {
+		if !seen[key] {
+			delete(owners, key)
+		}
+	}
+
+	names := readNetnsNames()
+
+	namespaces := make([]namespaceStats, 0, len(order))
+	for _, owner := range order {
+		pid := pidByNetns[owner.netns]
+		interfaces, err := readNetDev(pid)
+		if err != nil {
+			continue // Process may have exited since the scan
+		}
+
+		// Label the namespace with its init process, e.g. the container entrypoint
+		comm, _ := os.ReadFile(fmt.Sprintf("/proc/%d/comm", pid))
+
+		namespaces = append(namespaces, namespaceStats{
+			Netns:       owner.netns,
+			Name:        names[owner.netns],
+			ContainerID: owner.containerID,
+			Process:     strings.TrimSpace(string(comm)),
+			Interfaces:  interfaces,
+		})
+	}
+
+	return namespaces, nil
+}
+
+// readNetnsNames maps namespace identifiers to the names given to them with
+// "ip netns add". The bind mounts in /run/netns share the namespace's inode.
+func readNetnsNames() map[string]string {
+	names := make(map[string]string)
+
+	entries, err := os.ReadDir(netnsRunDir)
+	if err != nil {
+		return names
+	}
+
+	for _, entry := range entries {
+		var st syscall.Stat_t
+		if err := syscall.Stat(filepath.Join(netnsRunDir, entry.Name()), &st); err != nil {
+			continue
+		}
+		names[fmt.Sprintf("net:[%d]", st.Ino)] = entry.Name()
+	}
+
+	return names
+}
+
+// readNetDev parses /proc/<pid>/net/dev, which lists the interfaces of the
+// network namespace the process lives in. The loopback interface is skipped.
+func readNetDev(pid int32) ([]InterfaceStats, error) {
+	file, err := os.Open(fmt.Sprintf("/proc/%d/net/dev", pid))
+	if err != nil {
+		return nil, err
+	}
+	defer file.Close()
+
+	var stats []InterfaceStats
+	scanner := bufio.NewScanner(file)
+	for scanner.Scan() {
+		// Format: "  eth0: rx_bytes rx_packets ... tx_bytes tx_packets ..."
+		// after two header lines, which have no colon before the counters.
+		name, counters, ok := strings.Cut(scanner.Text(), ":")
+		if !ok {
+			continue
+		}
+		name = strings.TrimSpace(name)
+		fields := strings.Fields(counters)
+		if name == "lo" || len(fields) < 9 {
+			continue
+		}
+
+		bytesIn, errIn := strconv.ParseUint(fields[0], 10, 64)
+		bytesOut, errOut := strconv.ParseUint(fields[8], 10, 64)
+		if errIn != nil || errOut != nil {
+			continue
+		}
+
+		stats = append(stats, InterfaceStats{
+			Name:     name,
+			BytesIn:  bytesIn,
+			BytesOut: bytesOut,
+		})
+	}
+
+	return stats, scanner.Err()
+}
@@ -0,0 +1,8 @@
+//go:build !linux
+
+package collector
+
+// readNamespaces is a no-op on platforms without network namespaces.
+func readNamespaces(owners map[ProcessKey]netnsOwner, all bool) ([]namespaceStats, error) {
+	return nil, nil
+}
@@ -2,63 +2,127 @@ package collector
 
 import (
 	"fmt"
-	"path/filepath"
+	"netmon/internal/db"
+	"netmon/internal/protocols"
+	"netmon/internal/rules"
+	"netmon/internal/scope"
 	"strings"
+	"syscall"
 
 	"github.com/shirou/gopsutil/v3/net"
 	"github.com/shirou/gopsutil/v3/process"
 )
 
+// ProcessKey identifies a process. PIDs are recycled, so over time one PID
+// may name several processes; a PID and start time together do not.
+type ProcessKey struct {
+	PID       int32
+	StartTime int64 // milliseconds since the epoch; 0 if unknown
+}
+
 // ProcessNetInfo represents network activity for a specific process.
 type ProcessNetInfo struct {
 	PID         int32
+	StartTime   int64 // see ProcessKey
 	ProcessName string
 	AppName     string // User-friendly application name
-	Connections int    // Number of active connections
+	Identity    AppIdentity
+	Owner       *AppIdentity         // ancestor the traffic rolls up to, if rollup is enabled
+	Connections int                  // Number of active connections; see isActive
+	Classes     map[ConnClass]int    // Active connections per class
+	Activity    map[ConnClass]uint64 // Queued bytes sampled per class, if queue sampling is enabled
+	Listeners   []Listener           // Listening sockets; see isListener
+	Remotes     []Remote             // Hosts of active connections, possibly repeated
+
+	// Final byte counts of TCP sockets closed since the last update, for
+	// short-lived processes only
+	Closed map[ConnClass]ClosedTraffic
+}
+
+// socketContext holds what sockets are classified, weighted and matched to
+// interfaces with. Any field may be nil.
+type socketContext struct {
+	ports      *protocols.Map
+	scopes     *scope.Classifier
+	activity   map[socketKey]uint64 // queued bytes sampled since the last update
+	interfaces *interfaceAddrs
+	listening  map[listenPort]bool // ports local servers listen on; set by getActiveProcesses
+}
+
+// ConnClass classifies a connection by what it talks to.
+type ConnClass struct {
+	Protocol string // protocol/service by port, e.g. "HTTPS"
+	Scope    string // scope of the remote address, e.g. scope.WAN; "" if unconnected
+	Family   string // scope.IPv4 or scope.IPv6
 }
 
 // GetActiveProcesses returns information about processes with active network connections.
 func GetActiveProcesses() ([]ProcessNetInfo, error) {
-	// Get all network connections
-	connections, err := net.Connections("all")
+	return getActiveProcesses(newProcScanner(), nil, nil, &socketContext{})
+}
+
+// getActiveProcesses is GetActiveProcesses with a scanner that may keep its
+// caches between calls, an optional per-process identity cache, so identity
+// details are only resolved once per process, an optional rule set applied to
+// newly resolved identities, and the context used to classify and weight
+// sockets (the built-in port defaults and the host's local networks are not
+// applied if unset). The ports found listening are stored in the context.
+func getActiveProcesses(scanner *procScanner, identities map[ProcessKey]AppIdentity, ruleSet *rules.Set, sockets *socketContext) ([]ProcessNetInfo, error) {
+	// Get all TCP and UDP sockets; unix sockets carry no network traffic
+	connections, err := scanner.Connections()
 	if err != nil {
 		return nil, fmt.Errorf("get connections: %w", err)
 	}
+	sockets.listening = listeningPorts(connections)
 
 	// Map to track unique PIDs
 	pidMap := make(map[int32]*ProcessNetInfo)
 
 	for _, conn := range connections {
+		// Sockets of processes we may not inspect (e.g. other users'
+		// processes when not running as root) come without a PID
 		if conn.Pid == 0 {
+			if !isListener(conn) {
+				permissionDenied(pidMap).addSocket(conn, *sockets)
+			}
 			continue
 		}
 
-		// If we've already seen this PID, just increment connection count
+		// If we've already seen this PID, just count the socket
 		if info, exists := pidMap[conn.Pid]; exists {
-			info.Connections++
+			info.addSocket(conn, *sockets)
 			continue
 		}
 
-		// Get process information
-		proc, err := process.NewProcess(conn.Pid)
-		if err != nil {
-			continue // Process may have terminated
-		}
+		// Get process information, unless both name and identity are cached
+		startTime, _ := scanner.StartTime(conn.Pid)
+		key := ProcessKey{PID: conn.Pid, StartTime: startTime}
+		name, named := scanner.Name(conn.Pid)
+		identity, cached := identities[key]
+		if !named || !cached {
+			proc, err := process.NewProcess(conn.Pid)
+			if err != nil {
+				continue // Process may have terminated
+			}
 
-		name, err := proc.Name()
-		if err != nil {
-			continue
-		}
+			if !named {
+				if name, err = proc.Name(); err != nil {
+					permissionDenied(pidMap).addSocket(conn, *sockets)
+					continue
+				}
+			}
 
-		// Try to get a more user-friendly app name
-		appName := extractAppName(proc, name)
-
-		pidMap[conn.Pid] = &ProcessNetInfo{
-			PID:         conn.Pid,
-			ProcessName: name,
-			AppName:     appName,
-			Connections: 1,
+			if !cached {
+				identity = applyRules(resolveIdentity(proc, name), ruleSet)
+				if identities != nil {
+					identities[key] = identity
+				}
+			}
 		}
+
+		info := newProcessNetInfo(key, name, identity)
+		info.addSocket(conn, *sockets)
+		pidMap[conn.Pid] = info
 	}
 
 	// Convert map to slice
@@ -70,34 +134,147 @@ func GetActiveProcesses() ([]ProcessNetInfo, error) {
 	return result, nil
 }
 
-// extractAppName attempts to get a user-friendly application name.
-func extractAppName(proc *process.Process, processName string) string {
-	// Try to get the executable path
-	exe, err := proc.Exe()
-	if err != nil {
-		return cleanProcessName(processName)
-	}
-
-	// On macOS, apps are in .app bundles like:
-	// /Applications/Google Chrome.app/Contents/MacOS/Google Chrome
-	if strings.Contains(exe, ".app/") {
-		parts := strings.Split(exe, ".app/")
-		if len(parts) > 0 {
-			appPath := parts[0] + ".app"
-			appName := filepath.Base(appPath)
-			// Remove .app extension
-			return strings.TrimSuffix(appName, ".app")
-		}
+// Key returns the key identifying the process.
+func (info ProcessNetInfo) Key() ProcessKey {
+	return ProcessKey{PID: info.PID, StartTime: info.StartTime}
+}
+
+// permissionDeniedPID is the PID of the pseudo-process collecting sockets
+// whose owner could not be inspected. No real process has PID 0.
+const permissionDeniedPID = 0
+
+// permissionDeniedIdentity is the identity traffic of uninspectable
+// processes is credited to.
+var permissionDeniedIdentity = AppIdentity{
+	Key:  db.PermissionDeniedAppKey,
+	Name: db.PermissionDeniedApp,
+	UID:  -1,
+}
+
+// newProcessNetInfo creates the info of a process with no sockets recorded yet.
+func newProcessNetInfo(key ProcessKey, name string, identity AppIdentity) *ProcessNetInfo {
+	return &ProcessNetInfo{
+		PID:         key.PID,
+		StartTime:   key.StartTime,
+		ProcessName: name,
+		AppName:     identity.Name,
+		Identity:    identity,
+		Classes:     make(map[ConnClass]int),
+		Activity:    make(map[ConnClass]uint64),
+		Closed:      make(map[ConnClass]ClosedTraffic),
+	}
+}
+
+// permissionDenied returns the pseudo-process for uninspectable sockets,
+// adding it to pidMap on first use.
+func permissionDenied(pidMap map[int32]*ProcessNetInfo) *ProcessNetInfo {
+	info, ok := pidMap[permissionDeniedPID]
+	if !ok {
+		info = newProcessNetInfo(ProcessKey{PID: permissionDeniedPID}, db.PermissionDeniedApp, permissionDeniedIdentity)
+		pidMap[permissionDeniedPID] = info
+	}
+	return info
+}
+
+// addSocket records a socket of the process: listening sockets go to the
+// listener inventory, active ones count as classified connections, and idle
+// or closing ones are ignored.
+func (info *ProcessNetInfo) addSocket(conn net.ConnectionStat, sockets socketContext) {
+	transport := transportName(conn.Type)
+
+	if isListener(conn) {
+		info.Listeners = append(info.Listeners, Listener{
+			Transport: transport,
+			Address:   conn.Laddr.IP,
+			Port:      conn.Laddr.Port,
+		})
+		return
+	}
+
+	if !isActive(conn) {
+		return
 	}
 
-	return cleanProcessName(processName)
+	class := sockets.classify(transport, conn.Laddr, conn.Raddr)
+	info.Connections++
+	info.Classes[class]++
+	info.Activity[class] += sockets.activity[newSocketKey(transport, conn.Laddr, conn.Raddr)]
+	info.addRemote(conn.Laddr, conn.Raddr, sockets)
+}
+
+// addClosedSocket records a socket of a short-lived process that closed
+// since the last update. TCP sockets carry their final byte counts; UDP
+// sockets, which keep none, count as a connection instead.
+func (info *ProcessNetInfo) addClosedSocket(socket closedSocket, sockets socketContext) {
+	class := sockets.classify(socket.transport, socket.local, socket.remote)
+	info.addRemote(socket.local, socket.remote, sockets)
+	if socket.traffic == (ClosedTraffic{}) {
+		info.Connections++
+		info.Classes[class]++
+		return
+	}
+
+	closed := info.Closed[class]
+	closed.BytesIn += socket.traffic.BytesIn
+	closed.BytesOut += socket.traffic.BytesOut
+	info.Closed[class] = closed
+}
+
+// addRemote records the host of a connection and the interface it uses.
+func (info *ProcessNetInfo) addRemote(local, remote net.Addr, sockets socketContext) {
+	info.Remotes = append(info.Remotes, Remote{
+		Address:   normalizeIP(remote.IP),
+		Interface: sockets.interfaces.lookup(local.IP),
+	})
+}
+
+// classify returns the class of a connection. Connections on a port a local
+// server listens on are classified by that port rather than the client's.
+func (sockets socketContext) classify(transport string, local, remote net.Addr) ConnClass {
+	protocol := sockets.ports.Classify(transport, local.Port, remote.Port)
+	if sockets.listening[listenPort{transport, local.Port}] {
+		protocol = sockets.ports.ClassifyServer(transport, local.Port, remote.Port)
+	}
+	return ConnClass{
+		Protocol: protocol,
+		Scope:    sockets.scopes.Classify(remote.IP),
+		Family:   scope.Family(local.IP),
+	}
+}
+
+// activeTCPStates are the TCP states in which a socket can move data. Sockets
+// in TIME_WAIT, CLOSE_WAIT and the like linger without carrying traffic.
+var activeTCPStates = map[string]bool{
+	"ESTABLISHED": true,
+	"SYN_SENT":    true,
+	"SYN_RECV":    true,
+	"FIN_WAIT1":   true,
+}
+
+// isActive reports whether a socket may be carrying traffic: a TCP socket in
+// an active state or a connected UDP socket. Unconnected UDP sockets have no
+// peer to attribute traffic by.
+func isActive(conn net.ConnectionStat) bool {
+	if conn.Type == syscall.SOCK_DGRAM {
+		return conn.Raddr.Port != 0
+	}
+	return activeTCPStates[conn.Status]
+}
+
+// transportName returns the transport of a socket type as used by port maps.
+func transportName(socketType uint32) string {
+	if socketType == syscall.SOCK_DGRAM {
+		return protocols.UDP
+	}
+	return protocols.TCP
 }
 
 // cleanProcessName removes common suffixes and cleans up process names.
+// Names are otherwise left intact: stripping a trailing "d" for daemons
+// mangled names like "Android" and "Fd".
 func cleanProcessName(name string) string {
-	// Remove common helper/daemon suffixes
+	// Remove common helper suffixes
 	name = strings.TrimSuffix(name, "Helper")
-	name = strings.TrimSuffix(name, "d") // daemon suffix
 	name = strings.TrimSpace(name)
 
 	// Capitalize first letter
@@ -110,46 +287,127 @@ func cleanProcessName(name string) string {
 
 // ConnectionMapper tracks the mapping between network interfaces and processes.
 type ConnectionMapper struct {
-	lastSnapshot map[int32]ProcessNetInfo
+	lastSnapshot map[ProcessKey]ProcessNetInfo
+	identities   map[ProcessKey]AppIdentity
+	ancestors    map[ProcessKey]AppIdentity
+	rules        *rules.Set
+	scanner      *procScanner
+	sockets      socketContext
+	queues       *QueueSampler
+	shortLived   *ShortLivedTracker
+	exited       []ProcessNetInfo // short-lived processes seen by the tracker since the last update
+	rollupDepth  int
 }
 
 // NewConnectionMapper creates a new connection mapper.
 func NewConnectionMapper() *ConnectionMapper {
 	return &ConnectionMapper{
-		lastSnapshot: make(map[int32]ProcessNetInfo),
+		lastSnapshot: make(map[ProcessKey]ProcessNetInfo),
+		scanner:      newProcScanner(),
+		identities:   make(map[ProcessKey]AppIdentity),
+		ancestors:    make(map[ProcessKey]AppIdentity),
+		sockets:      socketContext{interfaces: &interfaceAddrs{}},
 	}
 }
 
+// SetRules sets the grouping rules applied to processes from now on.
+func (cm *ConnectionMapper) SetRules(set *rules.Set) {
+	cm.rules = set
+	cm.identities = make(map[ProcessKey]AppIdentity)
+	cm.ancestors = make(map[ProcessKey]AppIdentity)
+}
+
+// SetPorts sets the port map used to classify connections by protocol.
+func (cm *ConnectionMapper) SetPorts(ports *protocols.Map) {
+	cm.sockets.ports = ports
+}
+
+// SetScopes sets the classifier used to sort remote addresses into scopes.
+func (cm *ConnectionMapper) SetScopes(scopes *scope.Classifier) {
+	cm.sockets.scopes = scopes
+}
+
+// SetQueueSampler makes sockets weigh more the more data the sampler saw
+// queued on them since the last update.
+func (cm *ConnectionMapper) SetQueueSampler(sampler *QueueSampler) {
+	cm.queues = sampler
+}
+
+// SetShortLivedTracker makes the traffic of processes that started and exited
+// between updates count, with the exact byte counts of their TCP sockets.
+func (cm *ConnectionMapper) SetShortLivedTracker(tracker *ShortLivedTracker) {
+	cm.shortLived = tracker
+}
+
+// SetRollupDepth enables attributing a process's traffic to its ancestor up
+// to depth levels up the process tree. 0 disables depth-based rollup; rules
+// marking apps as owners still apply.
+func (cm *ConnectionMapper) SetRollupDepth(depth int) {
+	cm.rollupDepth = depth
+}
+
 // Update refreshes the process-to-connection mapping.
 func (cm *ConnectionMapper) Update() error {
-	processes, err := GetActiveProcesses()
+	cm.sockets.interfaces.refresh()
+	sockets := cm.sockets
+	if cm.queues != nil {
+		sockets.activity = cm.queues.Take()
+	}
+
+	processes, err := getActiveProcesses(cm.scanner, cm.identities, cm.rules, &sockets)
 	if err != nil {
 		return err
 	}
 
 	// Update snapshot
-	newSnapshot := make(map[int32]ProcessNetInfo)
+	newSnapshot := make(map[ProcessKey]ProcessNetInfo)
 	for _, p := range processes {
-		newSnapshot[p.PID] = p
+		newSnapshot[p.Key()] = p
 	}
 
+	// Forget identities of processes that no longer have connections
+	for key := range cm.identities {
+		if _, ok := newSnapshot[key]; !ok {
+			delete(cm.identities, key)
+		}
+	}
+
+	cm.resolveOwners(newSnapshot)
+
 	cm.lastSnapshot = newSnapshot
+	cm.exited = cm.exitedProcesses(sockets)
 	return nil
 }
 
-// GetActiveApps returns a list of application names currently using the network.
-func (cm *ConnectionMapper) GetActiveApps() []string {
-	appMap := make(map[string]bool)
-
-	for _, info := range cm.lastSnapshot {
-		appMap[info.AppName] = true
+// exitedProcesses groups the sockets closed by short-lived processes since
+// the last update by process.
+func (cm *ConnectionMapper) exitedProcesses(sockets socketContext) []ProcessNetInfo {
+	if cm.shortLived == nil {
+		return nil
 	}
 
-	apps := make([]string, 0, len(appMap))
-	for app := range appMap {
-		apps = append(apps, app)
+	byKey := make(map[ProcessKey]*ProcessNetInfo)
+	var order []ProcessKey
+	for _, socket := range cm.shortLived.take(cm.lastSnapshot) {
+		info, ok := byKey[socket.process]
+		if !ok {
+			info = newProcessNetInfo(socket.process, socket.name, applyRules(socket.identity, cm.rules))
+			byKey[socket.process] = info
+			order = append(order, socket.process)
+		}
+		info.addClosedSocket(socket, sockets)
 	}
 
-	return apps
+	rollup := cm.rollupDepth > 0 || cm.rules.HasOwners()
+	exited := make([]ProcessNetInfo, 0, len(order))
+	for _, key := range order {
+		info := byKey[key]
+		if rollup {
+			if owner, ok := cm.findOwner(*info, make(map[ProcessKey]bool)); ok {
+				info.Owner = &owner
+			}
+		}
+		exited = append(exited, *info)
+	}
+	return exited
 }
-
@@ -0,0 +1,86 @@
+package collector
+
+import (
+	"net"
+	"strconv"
+	"sync"
+	"time"
+
+	psnet "github.com/shirou/gopsutil/v3/net"
+)
+
+// socketKey identifies a socket by its transport and endpoints.
+type socketKey struct {
+	transport string
+	local     string
+	remote    string
+}
+
+// newSocketKey builds the key of a socket from gopsutil addresses.
+func newSocketKey(transport string, local, remote psnet.Addr) socketKey {
+	return socketKey{
+		transport: transport,
+		local:     endpoint(net.ParseIP(local.IP), local.Port),
+		remote:    endpoint(net.ParseIP(remote.IP), remote.Port),
+	}
+}
+
+// endpoint formats an address canonically, so IPv4-mapped IPv6 addresses and
+// their IPv4 form compare equal.
+func endpoint(ip net.IP, port uint32) string {
+	return net.JoinHostPort(ip.String(), strconv.Itoa(int(port)))
+}
+
+// QueueSampler samples the send and receive queues of sockets between
+// collections. Data sitting in a socket's queues shows it is moving traffic,
+// which the connection count alone cannot tell apart from an idle socket.
+type QueueSampler struct {
+	mu       sync.Mutex
+	activity map[socketKey]uint64
+}
+
+// NewQueueSampler creates a queue sampler. Sampling is only supported on
+// Linux; elsewhere no activity is ever recorded.
+func NewQueueSampler() *QueueSampler {
+	return &QueueSampler{activity: make(map[socketKey]uint64)}
+}
+
+// Run samples every interval until stop is closed.
+func (s *QueueSampler) Run(interval time.Duration, stop <-chan struct{}) {
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ticker.C:
+			s.Sample()
+		case <-stop:
+			return
+		}
+	}
+}
+
+// Sample adds the bytes currently queued on each socket to its activity.
+// Errors are ignored: a missed sample only makes weighting less precise.
+func (s *QueueSampler) Sample() {
+	queued, err := readSocketQueues()
+	if err != nil {
+		return
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	for key, bytes := range queued {
+		s.activity[key] += bytes
+	}
+}
+
+// Take returns the activity sampled since the last call and resets it.
+func (s *QueueSampler) Take() map[socketKey]uint64 {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	activity := s.activity
+	s.activity = make(map[socketKey]uint64)
+	return activity
+}
@@ -0,0 +1,24 @@
+package collector
+
+// readSocketQueues returns the bytes queued for sending and receiving on each
+// socket with data in its queues.
+func readSocketQueues() (map[socketKey]uint64, error) {
+	sockets, err := readSocketTables()
+	if err != nil {
+		return nil, err
+	}
+
+	queued := make(map[socketKey]uint64)
+	for _, socket := range sockets {
+		if socket.queued == 0 {
+			continue
+		}
+		key := socketKey{
+			transport: socket.transport,
+			local:     endpoint(socket.localIP, socket.localPort),
+			remote:    endpoint(socket.remoteIP, socket.remotePort),
+		}
+		queued[key] += socket.queued
+	}
+	return queued, nil
+}
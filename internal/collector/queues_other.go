@@ -0,0 +1,8 @@
+//go:build !linux
+
+package collector
+
+// readSocketQueues is a no-op on platforms without /proc/net.
+func readSocketQueues() (map[socketKey]uint64, error) {
+	return nil, nil
+}
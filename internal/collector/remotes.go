@@ -0,0 +1,108 @@
+package collector
+
+import (
+	"net"
+	"time"
+)
+
+// interfaceRefreshInterval is how often the addresses of the host's
+// interfaces are re-read.
+const interfaceRefreshInterval = time.Minute
+
+// Remote is a host a process has a connection to, and the interface the
+// connection goes through.
+type Remote struct {
+	Address   string // remote IP address
+	Interface string // interface holding the local address; "" if none does
+}
+
+// ActiveRemote is a remote host together with the app connected to it.
+type ActiveRemote struct {
+	Remote
+	Identity AppIdentity
+}
+
+// interfaceAddrs maps local addresses to the interfaces they belong to.
+type interfaceAddrs struct {
+	byIP      map[string]string
+	refreshed time.Time
+}
+
+// refresh re-reads the host's interface addresses if they are out of date.
+func (a *interfaceAddrs) refresh() {
+	if time.Since(a.refreshed) < interfaceRefreshInterval {
+		return
+	}
+	a.refreshed = time.Now()
+
+	ifaces, err := net.Interfaces()
+	if err != nil {
+		return
+	}
+	byIP := make(map[string]string)
+	for _, iface := range ifaces {
+		addrs, err := iface.Addrs()
+		if err != nil {
+			continue
+		}
+		for _, addr := range addrs {
+			if network, ok := addr.(*net.IPNet); ok {
+				byIP[network.IP.String()] = iface.Name
+			}
+		}
+	}
+	a.byIP = byIP
+}
+
+// lookup returns the interface holding a local address. A nil map knows none.
+func (a *interfaceAddrs) lookup(ip string) string {
+	if a == nil {
+		return ""
+	}
+	return a.byIP[normalizeIP(ip)]
+}
+
+// normalizeIP formats an address the way net.IP does, so IPv4-mapped IPv6
+// addresses and their IPv4 form compare equal.
+func normalizeIP(ip string) string {
+	if parsed := net.ParseIP(ip); parsed != nil {
+		return parsed.String()
+	}
+	return ip
+}
+
+// GetRemotes returns the hosts apps had active connections to at the last
+// update, including those of short-lived processes. Each app and remote is
+// listed once.
+func (cm *ConnectionMapper) GetRemotes() []ActiveRemote {
+	type remoteKey struct {
+		app string
+		Remote
+	}
+	seen := make(map[remoteKey]bool)
+	var remotes []ActiveRemote
+
+	add := func(info ProcessNetInfo) {
+		for _, remote := range info.Remotes {
+			key := remoteKey{info.Identity.Key, remote}
+			if seen[key] {
+				continue
+			}
+			seen[key] = true
+			remotes = append(remotes, ActiveRemote{Remote: remote, Identity: info.Identity})
+		}
+	}
+	for _, info := range cm.lastSnapshot {
+		add(info)
+	}
+	for _, info := range cm.exited {
+		add(info)
+	}
+
+	return remotes
+}
+
+// GetRemotes returns the hosts apps were connected to at the last collection.
+func (ac *AppCollector) GetRemotes() []ActiveRemote {
+	return ac.connectionMapper.GetRemotes()
+}
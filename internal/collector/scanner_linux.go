@@ -0,0 +1,375 @@
+package collector
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"sync"
+	"syscall"
+
+	"github.com/shirou/gopsutil/v3/net"
+)
+
+// procScanner lists sockets with their owning processes straight from /proc.
+// Unlike gopsutil, which reads every fd of every process on each call, it
+// remembers which process owns each socket inode and only reads the fds of
+// processes when a socket it cannot place appears.
+type procScanner struct {
+	procs   map[int32]*procEntry
+	owners  map[uint64]int32 // socket inode -> lowest PID holding it
+	orphans map[uint64]bool  // inodes no readable process held at the last full scan
+}
+
+// procEntry is what the scanner caches per process.
+type procEntry struct {
+	startTime int64    // see processStartTime; detects PID reuse
+	name      string   // resolved on first use
+	inodes    []uint64 // socket inodes found at the last fd scan
+	denied    bool     // the fd directory could not be read
+}
+
+func newProcScanner() *procScanner {
+	return &procScanner{
+		procs:   make(map[int32]*procEntry),
+		owners:  make(map[uint64]int32),
+		orphans: make(map[uint64]bool),
+	}
+}
+
+// Connections lists the TCP and UDP sockets of our network namespace in the
+// form gopsutil's net.Connections("inet") does. Sockets whose owner could not
+// be found have PID 0.
+func (s *procScanner) Connections() ([]net.ConnectionStat, error) {
+	sockets, err := readSocketTables()
+	if err != nil {
+		return nil, fmt.Errorf("read socket tables: %w", err)
+	}
+
+	pids, err := listPIDs()
+	if err != nil {
+		return nil, err
+	}
+	s.prune(pids)
+
+	// New processes are the likeliest owners of new sockets
+	for _, pid := range pids {
+		if _, ok := s.procs[pid]; !ok {
+			s.scanProcess(pid, s.addProcess(pid))
+		}
+	}
+
+	// A socket still unplaced was opened by a process we already know: look
+	// at those with sockets first, then at everyone else
+	if s.hasUnresolved(sockets) {
+		for pid, entry := range s.procs {
+			if len(entry.inodes) > 0 {
+				s.scanProcess(pid, entry)
+			}
+		}
+	}
+	if s.hasUnresolved(sockets) {
+		for pid, entry := range s.procs {
+			if len(entry.inodes) == 0 {
+				s.scanProcess(pid, entry)
+			}
+		}
+		s.orphans = s.unresolved(sockets)
+	}
+
+	verified := make(map[int32]bool)
+	connections := make([]net.ConnectionStat, 0, len(sockets))
+	for _, socket := range sockets {
+		if socket.inode == 0 {
+			continue
+		}
+
+		// Mark the PID looked up, not the one verify returns: that may be 0
+		// or another process, and the looked-up PID is what later sockets
+		// owned by it will be found under
+		pid := s.owners[socket.inode]
+		if owner := pid; owner != 0 && !verified[owner] {
+			pid = s.verify(owner, socket.inode)
+			verified[owner] = true
+		}
+
+		connections = append(connections, net.ConnectionStat{
+			Family: socket.family,
+			Type:   socketType(socket.transport),
+			Laddr:  net.Addr{IP: socket.localIP.String(), Port: socket.localPort},
+			Raddr:  net.Addr{IP: socket.remoteIP.String(), Port: socket.remotePort},
+			Status: socket.status,
+			Uids:   []int32{socket.uid},
+			Pid:    pid,
+		})
+	}
+
+	return connections, nil
+}
+
+// StartTime returns the start time of a PID seen by the last Connections call.
+func (s *procScanner) StartTime(pid int32) (int64, bool) {
+	entry, ok := s.procs[pid]
+	if !ok || entry.startTime == 0 {
+		return 0, false
+	}
+	return entry.startTime, true
+}
+
+// Name returns the process name of a PID seen by the last Connections call,
+// as gopsutil's Process.Name would.
+func (s *procScanner) Name(pid int32) (string, bool) {
+	entry, ok := s.procs[pid]
+	if !ok {
+		return "", false
+	}
+	if entry.name == "" {
+		entry.name = readProcessName(pid)
+	}
+	return entry.name, entry.name != ""
+}
+
+// prune forgets processes that have exited and the sockets they held.
+func (s *procScanner) prune(pids []int32) {
+	alive := make(map[int32]bool, len(pids))
+	for _, pid := range pids {
+		alive[pid] = true
+	}
+	for pid, entry := range s.procs {
+		if !alive[pid] {
+			s.forget(pid, entry)
+		}
+	}
+}
+
+// forget drops a process and its socket inodes from the caches.
+func (s *procScanner) forget(pid int32, entry *procEntry) {
+	for _, inode := range entry.inodes {
+		if s.owners[inode] == pid {
+			delete(s.owners, inode)
+		}
+	}
+	delete(s.procs, pid)
+}
+
+// addProcess caches a process seen for the first time.
+func (s *procScanner) addProcess(pid int32) *procEntry {
+	entry := &procEntry{startTime: readStartTime(pid)}
+	s.procs[pid] = entry
+	return entry
+}
+
+// verify checks that the owner of a socket is still the process that was
+// scanned, not a new one that reused its PID, and returns the socket's
+// current owner.
+func (s *procScanner) verify(pid int32, inode uint64) int32 {
+	entry := s.procs[pid]
+	if entry.startTime == readStartTime(pid) {
+		return pid
+	}
+
+	s.forget(pid, entry)
+	s.scanProcess(pid, s.addProcess(pid))
+	return s.owners[inode]
+}
+
+// scanProcess reads the fds of a process and records the sockets among them.
+// Processes whose fds we may not read are not scanned again.
+func (s *procScanner) scanProcess(pid int32, entry *procEntry) {
+	if entry.denied {
+		return
+	}
+
+	inodes, err := readSocketInodes(pid)
+	if err != nil {
+		entry.denied = os.IsPermission(err)
+		return
+	}
+
+	for _, inode := range entry.inodes {
+		if s.owners[inode] == pid {
+			delete(s.owners, inode)
+		}
+	}
+	entry.inodes = inodes
+
+	for _, inode := range inodes {
+		// Sockets shared after a fork go to the lowest PID, as with gopsutil
+		if owner, ok := s.owners[inode]; !ok || pid < owner {
+			s.owners[inode] = pid
+		}
+	}
+}
+
+// readSocketInodes returns the inodes of the sockets a process has open.
+func readSocketInodes(pid int32) ([]uint64, error) {
+	dir := fmt.Sprintf("/proc/%d/fd", pid)
+	f, err := os.Open(dir)
+	if err != nil {
+		return nil, err
+	}
+	fds, err := f.Readdirnames(-1)
+	f.Close()
+	if err != nil {
+		return nil, err
+	}
+
+	var inodes []uint64
+	for _, fd := range fds {
+		link, err := os.Readlink(filepath.Join(dir, fd))
+		if err != nil || !strings.HasPrefix(link, "socket:[") {
+			continue
+		}
+		inode, err := strconv.ParseUint(link[len("socket:["):len(link)-1], 10, 64)
+		if err != nil {
+			continue
+		}
+		inodes = append(inodes, inode)
+	}
+	return inodes, nil
+}
+
+// hasUnresolved reports whether a socket has no known owner and was not
+// already ownerless at the last full scan.
+func (s *procScanner) hasUnresolved(sockets []procSocket) bool {
+	for _, socket := range sockets {
+		if socket.inode == 0 || s.orphans[socket.inode] {
+			continue
+		}
+		if _, ok := s.owners[socket.inode]; !ok {
+			return true
+		}
+	}
+	return false
+}
+
+// unresolved returns the inodes of the sockets with no known owner.
+func (s *procScanner) unresolved(sockets []procSocket) map[uint64]bool {
+	inodes := make(map[uint64]bool)
+	for _, socket := range sockets {
+		if socket.inode == 0 {
+			continue
+		}
+		if _, ok := s.owners[socket.inode]; !ok {
+			inodes[socket.inode] = true
+		}
+	}
+	return inodes
+}
+
+// listPIDs returns the PIDs of all running processes.
+func listPIDs() ([]int32, error) {
+	f, err := os.Open("/proc")
+	if err != nil {
+		return nil, fmt.Errorf("read /proc: %w", err)
+	}
+	defer f.Close()
+
+	names, err := f.Readdirnames(-1)
+	if err != nil {
+		return nil, fmt.Errorf("read /proc: %w", err)
+	}
+
+	pids := make([]int32, 0, len(names))
+	for _, name := range names {
+		pid, err := strconv.ParseInt(name, 10, 32)
+		if err != nil {
+			continue
+		}
+		pids = append(pids, int32(pid))
+	}
+	return pids, nil
+}
+
+// readStartTime returns the start time of a process in milliseconds since
+// the epoch, or 0 if it cannot be read.
+func readStartTime(pid int32) int64 {
+	data, err := os.ReadFile(fmt.Sprintf("/proc/%d/stat", pid))
+	if err != nil {
+		return 0
+	}
+
+	// The command name may contain spaces and parentheses, so count fields
+	// from the last ')'. The start time is field 22; field 3 follows ')'.
+	stat := string(data)
+	fields := strings.Fields(stat[strings.LastIndexByte(stat, ')')+1:])
+	if len(fields) < 20 {
+		return 0
+	}
+	ticks, err := strconv.ParseInt(fields[19], 10, 64)
+	if err != nil {
+		return 0
+	}
+
+	bootTime := readBootTime()
+	if bootTime == 0 {
+		return 0
+	}
+	return bootTime*1000 + ticks*1000/clockTicks
+}
+
+// clockTicks is the unit of process times in /proc (USER_HZ), which the
+// kernel always reports as 100 per second.
+const clockTicks = 100
+
+var (
+	bootTimeOnce sync.Once
+	bootTime     int64
+)
+
+// readBootTime returns the boot time in seconds since the epoch from
+// /proc/stat, or 0 if it cannot be read.
+func readBootTime() int64 {
+	bootTimeOnce.Do(func() {
+		data, err := os.ReadFile("/proc/stat")
+		if err != nil {
+			return
+		}
+		for _, line := range strings.Split(string(data), "\n") {
+			if value, ok := strings.CutPrefix(line, "btime "); ok {
+				bootTime, _ = strconv.ParseInt(strings.TrimSpace(value), 10, 64)
+				return
+			}
+		}
+	})
+	return bootTime
+}
+
+// processStartTime returns the start time of a running process.
+func processStartTime(pid int32) (int64, bool) {
+	startTime := readStartTime(pid)
+	return startTime, startTime != 0
+}
+
+// readProcessName returns the name of a process like gopsutil does: the
+// kernel's command name, which is cut to 15 characters, extended from the
+// command line when it was cut.
+func readProcessName(pid int32) string {
+	comm, err := os.ReadFile(fmt.Sprintf("/proc/%d/comm", pid))
+	if err != nil {
+		return ""
+	}
+	name := strings.TrimSuffix(string(comm), "\n")
+	if len(name) < 15 {
+		return name
+	}
+
+	cmdline, err := os.ReadFile(fmt.Sprintf("/proc/%d/cmdline", pid))
+	if err != nil {
+		return name
+	}
+	args := strings.Split(string(cmdline), "\x00")
+	if base := filepath.Base(args[0]); strings.HasPrefix(base, name) {
+		return base
+	}
+	return name
+}
+
+// socketType returns the socket type of a transport.
+func socketType(transport string) uint32 {
+	if transport == "udp" {
+		return syscall.SOCK_DGRAM
+	}
+	return syscall.SOCK_STREAM
+}
@@ -0,0 +1,44 @@
+//go:build !linux
+
+package collector
+
+import (
+	"github.com/shirou/gopsutil/v3/net"
+	"github.com/shirou/gopsutil/v3/process"
+)
+
+// procScanner lists sockets through gopsutil where there is no /proc.
+type procScanner struct{}
+
+func newProcScanner() *procScanner {
+	return &procScanner{}
+}
+
+// Connections lists the TCP and UDP sockets of all processes.
+func (s *procScanner) Connections() ([]net.ConnectionStat, error) {
+	return net.Connections("inet")
+}
+
+// Name is not cached on this platform; process names come from gopsutil.
+func (s *procScanner) Name(pid int32) (string, bool) {
+	return "", false
+}
+
+// StartTime returns the start time of a process.
+func (s *procScanner) StartTime(pid int32) (int64, bool) {
+	return processStartTime(pid)
+}
+
+// processStartTime returns the start time of a running process in
+// milliseconds since the epoch.
+func processStartTime(pid int32) (int64, bool) {
+	proc, err := process.NewProcess(pid)
+	if err != nil {
+		return 0, false
+	}
+	startTime, err := proc.CreateTime()
+	if err != nil {
+		return 0, false
+	}
+	return startTime, true
+}
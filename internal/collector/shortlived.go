@@ -0,0 +1,135 @@
+package collector
+
+import (
+	"sync"
+	"time"
+
+	psnet "github.com/shirou/gopsutil/v3/net"
+)
+
+// shortLivedGrace is how long a short-lived process is remembered after it
+// exits, so the close of its sockets can still be matched to it.
+const shortLivedGrace = 5 * time.Second
+
+// shortLivedPollInterval is how often the sockets of new processes are looked
+// up. Processes that open and close a socket faster than this go unseen.
+const shortLivedPollInterval = 100 * time.Millisecond
+
+// shortLivedMaxAge is how long after it started a process is polled for
+// sockets. Older processes are left to the regular connection scan.
+const shortLivedMaxAge = 10 * time.Second
+
+// ClosedTraffic is the final byte count of sockets closed between collections.
+type ClosedTraffic struct {
+	BytesIn  uint64
+	BytesOut uint64
+}
+
+// closedSocket is a socket of a short-lived process, reported when it closed.
+type closedSocket struct {
+	process   ProcessKey
+	name      string
+	identity  AppIdentity
+	transport string
+	local     psnet.Addr
+	remote    psnet.Addr
+	traffic   ClosedTraffic // zero for UDP, whose sockets keep no byte counts
+	opened    time.Time     // when the socket was first seen, at most a poll after it opened
+	closed    time.Time
+}
+
+// sinceTime scales the socket's traffic down to the share that falls after
+// since, assuming it flowed evenly over the socket's lifetime. Bytes before
+// since belong to earlier collections, whose traffic was already split.
+func (s closedSocket) sinceTime(since time.Time) ClosedTraffic {
+	if since.IsZero() || !s.opened.Before(since) {
+		return s.traffic
+	}
+	if !s.closed.After(since) {
+		return ClosedTraffic{}
+	}
+
+	share := float64(s.closed.Sub(since)) / float64(s.closed.Sub(s.opened))
+	return ClosedTraffic{
+		BytesIn:  uint64(float64(s.traffic.BytesIn) * share),
+		BytesOut: uint64(float64(s.traffic.BytesOut) * share),
+	}
+}
+
+// ShortLivedTracker catches processes that start, use the network and exit
+// between two collections, which the connection scan never sees. It follows
+// process starts, finds the sockets of new processes while they run and
+// reports those sockets when they close, with their final byte counts.
+// Processes that open and close their sockets between two polls
+// (shortLivedPollInterval) are still missed.
+//
+// Only Linux is supported, and only with the privileges (CAP_NET_ADMIN) to
+// listen to the kernel's process and socket events.
+type ShortLivedTracker struct {
+	mu     sync.Mutex
+	closed []closedSocket
+	// Processes in the last collection's snapshot: their sockets are weighted like
+	// any other, and are only credited here if the process is gone by the next
+	established map[ProcessKey]bool
+	lastTake    time.Time
+	watcher     *shortLivedWatcher
+}
+
+// NewShortLivedTracker starts tracking short-lived processes. It returns an
+// error if tracking is not supported or not permitted.
+func NewShortLivedTracker() (*ShortLivedTracker, error) {
+	t := &ShortLivedTracker{established: make(map[ProcessKey]bool)}
+
+	watcher, err := startShortLivedWatcher(t)
+	if err != nil {
+		return nil, err
+	}
+	t.watcher = watcher
+	return t, nil
+}
+
+// Close stops tracking.
+func (t *ShortLivedTracker) Close() {
+	t.watcher.close()
+}
+
+// record adds a closed socket of a short-lived process.
+func (t *ShortLivedTracker) record(socket closedSocket) {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	t.closed = append(t.closed, socket)
+}
+
+// isEstablished reports whether a process was in the last collection's snapshot.
+func (t *ShortLivedTracker) isEstablished(key ProcessKey) bool {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	return t.established[key]
+}
+
+// take returns the sockets closed since the last call, with only the traffic
+// since then, and remembers the processes of the new snapshot as established.
+// Sockets of a process established in the last snapshot are left out if the
+// process is still in the new one, whose weighting already covers it; if it
+// exited in between, nothing else credits their traffic since the last call.
+func (t *ShortLivedTracker) take(snapshot map[ProcessKey]ProcessNetInfo) []closedSocket {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+
+	closed := make([]closedSocket, 0, len(t.closed))
+	for _, socket := range t.closed {
+		if _, running := snapshot[socket.process]; running && t.established[socket.process] {
+			continue
+		}
+		socket.traffic = socket.sinceTime(t.lastTake)
+		closed = append(closed, socket)
+	}
+	t.closed = nil
+
+	t.established = make(map[ProcessKey]bool, len(snapshot))
+	for key := range snapshot {
+		t.established[key] = true
+	}
+	t.lastTake = time.Now()
+	return closed
+}
@@ -0,0 +1,428 @@
+package collector
+
+import (
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"net"
+	"os"
+	"sync"
+	"syscall"
+	"time"
+
+	psnet "github.com/shirou/gopsutil/v3/net"
+	"github.com/shirou/gopsutil/v3/process"
+)
+
+// Process connector (linux/connector.h, linux/cn_proc.h).
+const (
+	cnIdxProc         = 1
+	cnValProc         = 1
+	procCnMcastListen = 1
+	procEventExec     = 0x00000002
+	procEventExit     = 0x80000000
+	cnMsgSize         = 20 // struct cn_msg without data
+	procEventHeader   = 16 // what, cpu and timestamp of struct proc_event
+)
+
+// Socket destroy notifications (linux/sock_diag.h, linux/inet_diag.h).
+const (
+	sockDiagByFamily = 20
+	// SKNLGRP_INET_TCP_DESTROY, INET_UDP_DESTROY, INET6_TCP_DESTROY and
+	// INET6_UDP_DESTROY as a group mask
+	sockDiagDestroyGroups = 1<<0 | 1<<1 | 1<<2 | 1<<3
+	inetDiagMsgSize       = 72
+	inetDiagInfo          = 2  // attribute holding struct tcp_info
+	inetDiagProtocol      = 10 // attribute holding the IP protocol
+	tcpInfoBytesAcked     = 120
+	tcpInfoBytesReceived  = 128
+)
+
+// netlinkReadTimeout bounds how long a read blocks, so the watcher notices
+// it was closed.
+const netlinkReadTimeout = time.Second
+
+// shortLivedWatcher follows process starts and exits through the process
+// connector and socket closes through sock_diag destroy notifications.
+type shortLivedWatcher struct {
+	tracker *ShortLivedTracker
+	procFD  int
+	diagFD  int
+	stop    chan struct{}
+	wg      sync.WaitGroup
+
+	mu      sync.Mutex
+	procs   map[ProcessKey]*youngProcess
+	running map[int32]ProcessKey      // young processes that have not exited
+	sockets map[socketKey]youngSocket // sockets found in young processes
+}
+
+// youngSocket is a socket found in a young process.
+type youngSocket struct {
+	process ProcessKey
+	found   time.Time
+}
+
+// youngProcess is a process started since tracking began.
+type youngProcess struct {
+	started  time.Time
+	exited   time.Time // zero while running
+	inodes   map[uint64]bool
+	name     string
+	identity AppIdentity
+	resolved bool
+}
+
+func startShortLivedWatcher(tracker *ShortLivedTracker) (*shortLivedWatcher, error) {
+	if os.Geteuid() != 0 {
+		return nil, errors.New("needs root (CAP_NET_ADMIN)")
+	}
+
+	procFD, err := openProcConnector()
+	if err != nil {
+		return nil, fmt.Errorf("process connector: %w", err)
+	}
+	diagFD, err := openNetlink(syscall.NETLINK_INET_DIAG, sockDiagDestroyGroups)
+	if err != nil {
+		syscall.Close(procFD)
+		return nil, fmt.Errorf("socket destroy events: %w", err)
+	}
+
+	w := &shortLivedWatcher{
+		tracker: tracker,
+		procFD:  procFD,
+		diagFD:  diagFD,
+		stop:    make(chan struct{}),
+		procs:   make(map[ProcessKey]*youngProcess),
+		running: make(map[int32]ProcessKey),
+		sockets: make(map[socketKey]youngSocket),
+	}
+
+	w.wg.Add(3)
+	go w.readLoop(procFD, w.handleProcEvent)
+	go w.readLoop(diagFD, w.handleDestroyEvent)
+	go w.pollLoop()
+	return w, nil
+}
+
+func (w *shortLivedWatcher) close() {
+	close(w.stop)
+	w.wg.Wait()
+	syscall.Close(w.procFD)
+	syscall.Close(w.diagFD)
+}
+
+// openNetlink opens a netlink socket subscribed to the given multicast groups.
+func openNetlink(protocol, groups int) (int, error) {
+	fd, err := syscall.Socket(syscall.AF_NETLINK, syscall.SOCK_DGRAM|syscall.SOCK_CLOEXEC, protocol)
+	if err != nil {
+		return -1, err
+	}
+
+	timeout := syscall.NsecToTimeval(netlinkReadTimeout.Nanoseconds())
+	if err := syscall.SetsockoptTimeval(fd, syscall.SOL_SOCKET, syscall.SO_RCVTIMEO, &timeout); err != nil {
+		syscall.Close(fd)
+		return -1, err
+	}
+
+	if err := syscall.Bind(fd, &syscall.SockaddrNetlink{Family: syscall.AF_NETLINK, Groups: uint32(groups)}); err != nil {
+		syscall.Close(fd)
+		return -1, err
+	}
+	return fd, nil
+}
+
+// openProcConnector subscribes to process events.
+func openProcConnector() (int, error) {
+	fd, err := openNetlink(syscall.NETLINK_CONNECTOR, cnIdxProc)
+	if err != nil {
+		return -1, err
+	}
+
+	msg := make([]byte, syscall.NLMSG_HDRLEN+cnMsgSize+4)
+	binary.NativeEndian.PutUint32(msg[0:], uint32(len(msg)))
+	binary.NativeEndian.PutUint16(msg[4:], syscall.NLMSG_DONE)
+	binary.NativeEndian.PutUint32(msg[12:], uint32(os.Getpid()))
+	cn := msg[syscall.NLMSG_HDRLEN:]
+	binary.NativeEndian.PutUint32(cn[0:], cnIdxProc)
+	binary.NativeEndian.PutUint32(cn[4:], cnValProc)
+	binary.NativeEndian.PutUint16(cn[16:], 4)
+	binary.NativeEndian.PutUint32(cn[cnMsgSize:], procCnMcastListen)
+
+	if err := syscall.Sendto(fd, msg, 0, &syscall.SockaddrNetlink{Family: syscall.AF_NETLINK}); err != nil {
+		syscall.Close(fd)
+		return -1, err
+	}
+	return fd, nil
+}
+
+// readLoop reads netlink messages from fd until the watcher is closed.
+// Messages lost to a full receive buffer are skipped.
+func (w *shortLivedWatcher) readLoop(fd int, handle func(syscall.NetlinkMessage)) {
+	defer w.wg.Done()
+
+	buf := make([]byte, 64*1024)
+	for {
+		select {
+		case <-w.stop:
+			return
+		default:
+		}
+
+		n, _, err := syscall.Recvfrom(fd, buf, 0)
+		if err != nil {
+			continue // timeout, interrupted read or lost messages (ENOBUFS)
+		}
+
+		msgs, err := syscall.ParseNetlinkMessage(buf[:n])
+		if err != nil {
+			continue
+		}
+		for _, msg := range msgs {
+			handle(msg)
+		}
+	}
+}
+
+// handleProcEvent records process starts and exits.
+func (w *shortLivedWatcher) handleProcEvent(msg syscall.NetlinkMessage) {
+	data := msg.Data
+	if len(data) < cnMsgSize+procEventHeader+8 {
+		return
+	}
+
+	event := data[cnMsgSize:]
+	what := binary.NativeEndian.Uint32(event[0:])
+	pid := int32(binary.NativeEndian.Uint32(event[procEventHeader:]))
+	tgid := int32(binary.NativeEndian.Uint32(event[procEventHeader+4:]))
+	if pid != tgid {
+		return // a thread, not a process
+	}
+
+	// The start time tells this process apart from earlier and later ones
+	// with the same PID. Exec keeps the start time of the forked process.
+	var key ProcessKey
+	if what == procEventExec {
+		startTime, ok := processStartTime(pid)
+		if !ok {
+			return // already gone
+		}
+		key = ProcessKey{PID: pid, StartTime: startTime}
+	}
+
+	w.mu.Lock()
+	defer w.mu.Unlock()
+
+	switch what {
+	case procEventExec:
+		w.procs[key] = &youngProcess{started: time.Now(), inodes: make(map[uint64]bool)}
+		w.running[pid] = key
+	case procEventExit:
+		if key, ok := w.running[pid]; ok {
+			w.procs[key].exited = time.Now()
+			delete(w.running, pid)
+		}
+	}
+}
+
+// handleDestroyEvent reports the close of a socket of a young process.
+func (w *shortLivedWatcher) handleDestroyEvent(msg syscall.NetlinkMessage) {
+	data := msg.Data
+	if msg.Header.Type != sockDiagByFamily || len(data) < inetDiagMsgSize {
+		return
+	}
+
+	var localIP, remoteIP net.IP
+	switch data[0] {
+	case syscall.AF_INET:
+		localIP, remoteIP = net.IP(data[8:12]), net.IP(data[24:28])
+	case syscall.AF_INET6:
+		localIP, remoteIP = net.IP(data[8:24]), net.IP(data[24:40])
+	default:
+		return
+	}
+	localPort := uint32(binary.BigEndian.Uint16(data[4:]))
+	remotePort := uint32(binary.BigEndian.Uint16(data[6:]))
+
+	transport := "udp"
+	var traffic ClosedTraffic
+	for attrs := data[inetDiagMsgSize:]; len(attrs) >= 4; {
+		length := int(binary.NativeEndian.Uint16(attrs[0:]))
+		if length < 4 || length > len(attrs) {
+			break
+		}
+		value := attrs[4:length]
+
+		switch binary.NativeEndian.Uint16(attrs[2:]) {
+		case inetDiagInfo:
+			transport = "tcp"
+			if len(value) >= tcpInfoBytesReceived+8 {
+				traffic.BytesOut = binary.NativeEndian.Uint64(value[tcpInfoBytesAcked:])
+				traffic.BytesIn = binary.NativeEndian.Uint64(value[tcpInfoBytesReceived:])
+			}
+		case inetDiagProtocol:
+			if len(value) >= 1 && value[0] == syscall.IPPROTO_TCP {
+				transport = "tcp"
+			}
+		}
+
+		aligned := (length + syscall.RTA_ALIGNTO - 1) &^ (syscall.RTA_ALIGNTO - 1)
+		if aligned > len(attrs) {
+			break
+		}
+		attrs = attrs[aligned:]
+	}
+
+	key := socketKey{
+		transport: transport,
+		local:     endpoint(localIP, localPort),
+		remote:    endpoint(remoteIP, remotePort),
+	}
+
+	w.mu.Lock()
+	socket, ok := w.sockets[key]
+	delete(w.sockets, key)
+	var proc youngProcess
+	if young, found := w.procs[socket.process]; found {
+		proc = *young
+	}
+	w.mu.Unlock()
+
+	if !ok || !proc.resolved {
+		return
+	}
+
+	w.tracker.record(closedSocket{
+		process:   socket.process,
+		name:      proc.name,
+		identity:  proc.identity,
+		transport: transport,
+		local:     psnet.Addr{IP: localIP.String(), Port: localPort},
+		remote:    psnet.Addr{IP: remoteIP.String(), Port: remotePort},
+		traffic:   traffic,
+		opened:    socket.found,
+		closed:    time.Now(),
+	})
+}
+
+// pollLoop looks up the sockets of young processes until the watcher is closed.
+func (w *shortLivedWatcher) pollLoop() {
+	defer w.wg.Done()
+
+	ticker := time.NewTicker(shortLivedPollInterval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ticker.C:
+			w.poll()
+		case <-w.stop:
+			return
+		}
+	}
+}
+
+// poll finds new sockets of young processes, resolving the identity of a
+// process once it is seen with a socket, and forgets processes that exited
+// or grew old.
+func (w *shortLivedWatcher) poll() {
+	now := time.Now()
+	var keys []ProcessKey
+
+	w.mu.Lock()
+	for key, proc := range w.procs {
+		switch {
+		case !proc.exited.IsZero() && now.Sub(proc.exited) > shortLivedGrace,
+			proc.exited.IsZero() && now.Sub(proc.started) > shortLivedMaxAge:
+			w.forget(key)
+		case proc.exited.IsZero() && !w.tracker.isEstablished(key):
+			keys = append(keys, key)
+		}
+	}
+	w.mu.Unlock()
+
+	// Read fds without holding the lock; events keep flowing meanwhile
+	found := make(map[uint64]ProcessKey)
+	for _, key := range keys {
+		inodes, err := readSocketInodes(key.PID)
+		if err != nil {
+			continue
+		}
+		w.mu.Lock()
+		if proc, ok := w.procs[key]; ok && proc.exited.IsZero() {
+			for _, inode := range inodes {
+				if !proc.inodes[inode] {
+					proc.inodes[inode] = true
+					found[inode] = key
+				}
+			}
+		}
+		w.mu.Unlock()
+	}
+	if len(found) == 0 {
+		return
+	}
+
+	sockets, err := readSocketTables()
+	if err != nil {
+		return
+	}
+
+	identities := make(map[ProcessKey]*youngProcess)
+	for _, key := range found {
+		if _, ok := identities[key]; !ok {
+			identities[key] = resolveYoungProcess(key.PID)
+		}
+	}
+
+	w.mu.Lock()
+	defer w.mu.Unlock()
+
+	for _, socket := range sockets {
+		process, ok := found[socket.inode]
+		if !ok {
+			continue
+		}
+		key := socketKey{
+			transport: socket.transport,
+			local:     endpoint(socket.localIP, socket.localPort),
+			remote:    endpoint(socket.remoteIP, socket.remotePort),
+		}
+		w.sockets[key] = youngSocket{process: process, found: now}
+	}
+
+	for key, resolved := range identities {
+		proc, ok := w.procs[key]
+		if !ok || resolved == nil || proc.resolved {
+			continue
+		}
+		proc.name, proc.identity, proc.resolved = resolved.name, resolved.identity, true
+	}
+}
+
+// forget drops a young process and its sockets. The caller holds w.mu.
+func (w *shortLivedWatcher) forget(process ProcessKey) {
+	delete(w.procs, process)
+	if w.running[process.PID] == process {
+		delete(w.running, process.PID)
+	}
+	for key, socket := range w.sockets {
+		if socket.process == process {
+			delete(w.sockets, key)
+		}
+	}
+}
+
+// resolveYoungProcess reads the name and identity of a running process, or
+// returns nil if it has exited.
+func resolveYoungProcess(pid int32) *youngProcess {
+	proc, err := process.NewProcess(pid)
+	if err != nil {
+		return nil
+	}
+	name, err := proc.Name()
+	if err != nil {
+		return nil
+	}
+	return &youngProcess{name: name, identity: resolveIdentity(proc, name)}
+}
@@ -0,0 +1,14 @@
+//go:build !linux
+
+package collector
+
+import "errors"
+
+// shortLivedWatcher is not available on this platform.
+type shortLivedWatcher struct{}
+
+func startShortLivedWatcher(tracker *ShortLivedTracker) (*shortLivedWatcher, error) {
+	return nil, errors.New("only supported on Linux")
+}
+
+func (w *shortLivedWatcher) close() {}
@@ -0,0 +1,69 @@
+package collector
+
+import (
+	"testing"
+	"time"
+)
+
+// TestShortLivedTake follows a socket that closes between two collections,
+// N and N+1, depending on whether its process was in the snapshots.
+func TestShortLivedTake(t *testing.T) {
+	process := ProcessKey{PID: 4242, StartTime: 1760000000000}
+	other := ProcessKey{PID: 4343, StartTime: 1760000000500}
+
+	collectionN := time.Now()
+	socket := closedSocket{
+		process: process,
+		name:    "curl",
+		traffic: ClosedTraffic{BytesIn: 1000, BytesOut: 400},
+		opened:  collectionN.Add(-time.Second),
+		closed:  collectionN.Add(time.Second),
+	}
+
+	tests := []struct {
+		name  string
+		inN   bool // process in the snapshot taken at collection N
+		inN1  bool // process in the snapshot taken at collection N+1
+		want  bool // socket credited at N+1
+		bytes ClosedTraffic
+	}{
+		// Traffic after N is weighted with the process's other connections
+		{name: "in both snapshots", inN: true, inN1: true},
+		// Exited before N+1: only the half of its lifetime after N is left to credit
+		{name: "in N, gone by N+1", inN: true, want: true, bytes: ClosedTraffic{BytesIn: 500, BytesOut: 200}},
+		{name: "in neither snapshot", want: true, bytes: ClosedTraffic{BytesIn: 500, BytesOut: 200}},
+		{name: "only in N+1", inN1: true, want: true, bytes: ClosedTraffic{BytesIn: 500, BytesOut: 200}},
+	}
+
+	snapshotOf := func(in bool) map[ProcessKey]ProcessNetInfo {
+		snapshot := map[ProcessKey]ProcessNetInfo{other: {}}
+		if in {
+			snapshot[process] = ProcessNetInfo{}
+		}
+		return snapshot
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			tracker := &ShortLivedTracker{established: make(map[ProcessKey]bool)}
+			tracker.take(snapshotOf(tt.inN))
+			tracker.lastTake = collectionN
+
+			tracker.record(socket)
+			closed := tracker.take(snapshotOf(tt.inN1))
+
+			if !tt.want {
+				if len(closed) != 0 {
+					t.Fatalf("take() = %+v, want no sockets", closed)
+				}
+				return
+			}
+			if len(closed) != 1 {
+				t.Fatalf("take() returned %d sockets, want 1", len(closed))
+			}
+			if closed[0].traffic != tt.bytes {
+				t.Errorf("traffic = %+v, want %+v", closed[0].traffic, tt.bytes)
+			}
+		})
+	}
+}
@@ -0,0 +1,144 @@
+package collector
+
+import (
+	"bufio"
+	"encoding/hex"
+	"fmt"
+	"net"
+	"os"
+	"strconv"
+	"strings"
+	"syscall"
+)
+
+// socketTables are the /proc/net tables listing the TCP and UDP sockets of
+// our network namespace.
+var socketTables = []struct {
+	path      string
+	transport string
+	family    uint32
+}{
+	{"/proc/net/tcp", "tcp", syscall.AF_INET},
+	{"/proc/net/tcp6", "tcp", syscall.AF_INET6},
+	{"/proc/net/udp", "udp", syscall.AF_INET},
+	{"/proc/net/udp6", "udp", syscall.AF_INET6},
+}
+
+// tcpStates maps the hex state codes of /proc/net/tcp to the names gopsutil uses.
+var tcpStates = map[string]string{
+	"01": "ESTABLISHED",
+	"02": "SYN_SENT",
+	"03": "SYN_RECV",
+	"04": "FIN_WAIT1",
+	"05": "FIN_WAIT2",
+	"06": "TIME_WAIT",
+	"07": "CLOSE",
+	"08": "CLOSE_WAIT",
+	"09": "LAST_ACK",
+	"0A": "LISTEN",
+	"0B": "CLOSING",
+}
+
+// procSocket is a socket as listed in a /proc/net table.
+type procSocket struct {
+	transport  string
+	family     uint32
+	localIP    net.IP
+	localPort  uint32
+	remoteIP   net.IP
+	remotePort uint32
+	status     string // see tcpStates; "NONE" for UDP
+	queued     uint64 // bytes in the send and receive queues
+	uid        int32
+	inode      uint64 // 0 for sockets no longer owned by a process, e.g. in TIME_WAIT
+}
+
+// readSocketTables lists the sockets of all socket tables. Missing tables
+// (e.g. tcp6 with IPv6 disabled) are skipped.
+func readSocketTables() ([]procSocket, error) {
+	var sockets []procSocket
+	for _, table := range socketTables {
+		var err error
+		sockets, err = readSocketTable(table.path, table.transport, table.family, sockets)
+		if err != nil && !os.IsNotExist(err) {
+			return nil, err
+		}
+	}
+	return sockets, nil
+}
+
+// readSocketTable parses one /proc/net socket table, appending to sockets.
+func readSocketTable(path, transport string, family uint32, sockets []procSocket) ([]procSocket, error) {
+	file, err := os.Open(path)
+	if err != nil {
+		return sockets, err
+	}
+	defer file.Close()
+
+	scanner := bufio.NewScanner(file)
+	scanner.Scan() // header
+	for scanner.Scan() {
+		// Format: "sl local_address rem_address st tx_queue:rx_queue tr:tm->when retrnsmt uid timeout inode ..."
+		fields := strings.Fields(scanner.Text())
+		if len(fields) < 10 {
+			continue
+		}
+
+		localIP, localPort, errLocal := parseHexEndpoint(fields[1])
+		remoteIP, remotePort, errRemote := parseHexEndpoint(fields[2])
+		if errLocal != nil || errRemote != nil {
+			continue
+		}
+
+		socket := procSocket{
+			transport:  transport,
+			family:     family,
+			localIP:    localIP,
+			localPort:  localPort,
+			remoteIP:   remoteIP,
+			remotePort: remotePort,
+			status:     "NONE",
+			uid:        -1,
+		}
+		if transport == "tcp" {
+			socket.status = tcpStates[fields[3]]
+		}
+		if txHex, rxHex, ok := strings.Cut(fields[4], ":"); ok {
+			tx, _ := strconv.ParseUint(txHex, 16, 64)
+			rx, _ := strconv.ParseUint(rxHex, 16, 64)
+			socket.queued = tx + rx
+		}
+		if uid, err := strconv.ParseInt(fields[7], 10, 32); err == nil {
+			socket.uid = int32(uid)
+		}
+		socket.inode, _ = strconv.ParseUint(fields[9], 10, 64)
+
+		sockets = append(sockets, socket)
+	}
+
+	return sockets, scanner.Err()
+}
+
+// parseHexEndpoint decodes an address such as "0100007F:0050" from a /proc/net
+// table. Addresses are stored as 32-bit words in host (little-endian) order.
+func parseHexEndpoint(s string) (net.IP, uint32, error) {
+	addrHex, portHex, ok := strings.Cut(s, ":")
+	if !ok {
+		return nil, 0, fmt.Errorf("malformed endpoint %q", s)
+	}
+
+	raw, err := hex.DecodeString(addrHex)
+	if err != nil || (len(raw) != net.IPv4len && len(raw) != net.IPv6len) {
+		return nil, 0, fmt.Errorf("malformed address %q", addrHex)
+	}
+	for i := 0; i < len(raw); i += 4 {
+		raw[i], raw[i+1], raw[i+2], raw[i+3] = raw[i+3], raw[i+2], raw[i+1], raw[i]
+	}
+
+	port, err := strconv.ParseUint(portHex, 16, 16)
+	if err != nil {
+		return nil, 0, fmt.Errorf("malformed port %q", portHex)
+	}
+
+	return net.IP(raw), uint32(port), nil
+}
@@ -0,0 +1,89 @@
+package collector
+
+import "github.com/shirou/gopsutil/v3/process"
+
+// maxOwnerDepth bounds how far up the process tree we look for an owner rule.
+const maxOwnerDepth = 16
+
+// resolveOwners sets the Owner of each process in the snapshot. The owner is
+// the nearest ancestor matched by an owner rule or, failing that, the
+// ancestor rollupDepth levels up (or the highest one below init).
+func (cm *ConnectionMapper) resolveOwners(snapshot map[ProcessKey]ProcessNetInfo) {
+	if cm.rollupDepth <= 0 && !cm.rules.HasOwners() {
+		return
+	}
+
+	used := make(map[ProcessKey]bool)
+	for key, info := range snapshot {
+		if owner, ok := cm.findOwner(info, used); ok {
+			info.Owner = &owner
+			snapshot[key] = info
+		}
+	}
+
+	// Forget ancestors that are no longer part of any process chain
+	for key := range cm.ancestors {
+		if !used[key] {
+			delete(cm.ancestors, key)
+		}
+	}
+}
+
+// findOwner walks up from a process's parent looking for its owner.
+func (cm *ConnectionMapper) findOwner(info ProcessNetInfo, used map[ProcessKey]bool) (AppIdentity, bool) {
+	var fallback AppIdentity
+	found := false
+
+	identity := info.Identity
+	pid, childStart := identity.PPID, info.StartTime
+	for depth := 1; pid > 1 && depth <= maxOwnerDepth; depth++ {
+		ancestor, key, ok := cm.ancestor(pid)
+		// A parent cannot have started after its child: if it did, the
+		// parent exited and its PID now belongs to another process
+		if !ok || (childStart != 0 && key.StartTime > childStart) {
+			break
+		}
+		used[key] = true
+
+		if ancestor.Key != identity.Key {
+			if ancestor.Owner {
+				return ancestor, true
+			}
+			if depth <= cm.rollupDepth {
+				fallback, found = ancestor, true
+			}
+		}
+		pid, childStart = ancestor.PPID, key.StartTime
+	}
+
+	return fallback, found
+}
+
+// ancestor resolves the identity of a process that may have no connections of its own.
+func (cm *ConnectionMapper) ancestor(pid int32) (AppIdentity, ProcessKey, bool) {
+	startTime, ok := processStartTime(pid)
+	if !ok {
+		return AppIdentity{}, ProcessKey{}, false
+	}
+	key := ProcessKey{PID: pid, StartTime: startTime}
+
+	if identity, ok := cm.identities[key]; ok {
+		return identity, key, true
+	}
+	if identity, ok := cm.ancestors[key]; ok {
+		return identity, key, true
+	}
+
+	proc, err := process.NewProcess(pid)
+	if err != nil {
+		return AppIdentity{}, ProcessKey{}, false
+	}
+	name, err := proc.Name()
+	if err != nil {
+		return AppIdentity{}, ProcessKey{}, false
+	}
+
+	identity := applyRules(resolveIdentity(proc, name), cm.rules)
+	cm.ancestors[key] = identity
+	return identity, key, true
+}
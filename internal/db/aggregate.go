@@ -0,0 +1,304 @@
+package db
+
+import (
+	"fmt"
+	"strings"
+)
+
+// Labels the aggregate queries match log entries without a value by.
+const (
+	UnknownUser          = "(unknown)"    // process owner could not be read
+	UnclassifiedProtocol = "Unclassified" // recorded before protocols were tracked
+)
+
+// GroupTotals is the app traffic of a range with one value of a log column,
+// e.g. one protocol or destination scope.
+type GroupTotals struct {
+	Label    string // "" for entries without a value
+	UID      int32  // ColumnUser only: the UID of the user, -1 if unknown
+	Apps     int
+	BytesIn  uint64
+	BytesOut uint64
+}
+
+// AppColumn is a column of app_traffic_logs that app traffic can be grouped by.
+type AppColumn string
+
+// Columns GetAppGroupTotals can group by.
+const (
+	ColumnProtocol    AppColumn = "protocol"
+	ColumnScope       AppColumn = "scope"
+	ColumnFamily      AppColumn = "family"
+	ColumnContainer   AppColumn = "container_id"
+	ColumnSystemdUnit AppColumn = "systemd_unit"
+	ColumnUser        AppColumn = "user" // username, else UID, else UnknownUser
+)
+
+// userExpr labels the user of an app log entry: the username, else the
+// numeric UID, else UnknownUser.
+const userExpr = `CASE WHEN l.username != '' THEN l.username
+                       WHEN l.uid >= 0 THEN CAST(l.uid AS TEXT)
+                       ELSE '` + UnknownUser + `' END`
+
+// AppLogFilter narrows app traffic aggregates. Empty fields match everything.
+type AppLogFilter struct {
+	AppKey   string // stable app key
+	User     string // username or numeric UID, or UnknownUser
+	Protocol string // matched case-insensitively, or UnclassifiedProtocol
+	Scope    string
+}
+
+// where returns the conditions of the filter on app_traffic_logs l, to be
+// appended to a WHERE clause, and their arguments.
+func (f AppLogFilter) where() (string, []interface{}) {
+	var clause strings.Builder
+	var args []interface{}
+
+	if f.AppKey != "" {
+		clause.WriteString(` AND (l.app_id IN (SELECT id FROM apps WHERE app_key = ?)
+		                          OR (l.app_id IS NULL AND 'name:' || l.app_name = ?))`)
+		args = append(args, f.AppKey, f.AppKey)
+	}
+	if f.User != "" {
+		clause.WriteString(` AND (` + userExpr + ` = ?
+		                          OR (l.uid >= 0 AND CAST(l.uid AS TEXT) = ?))`)
+		args = append(args, f.User, f.User)
+	}
+	if f.Protocol != "" {
+		clause.WriteString(` AND COALESCE(NULLIF(l.protocol, ''), '` + UnclassifiedProtocol + `') = ? COLLATE NOCASE`)
+		args = append(args, f.Protocol)
+	}
+	if f.Scope != "" {
+		clause.WriteString(` AND l.scope = ?`)
+		args = append(args, f.Scope)
+	}
+
+	return clause.String(), args
+}
+
+// appGroupExpr tells the apps of app logs apart without joining the apps
+// table: app keys are unique, so an app ID stands for one key, and logs
+// without one are keyed by name, as ScanSampleTotals does.
+const appGroupExpr = `COALESCE(l.app_id, 'name:' || l.app_name)`
+
+// GetAppGroupTotals sums the app traffic of a range per value of column,
+// counting the distinct apps of each value.
+func (db *DB) GetAppGroupTotals(startTime, endTime int64, column AppColumn, filter AppLogFilter) ([]GroupTotals, error) {
+	labelExpr, uidExpr := "l."+string(column), "-1"
+	switch column {
+	case ColumnProtocol, ColumnScope, ColumnFamily, ColumnContainer, ColumnSystemdUnit:
+	case ColumnUser:
+		labelExpr, uidExpr = userExpr, "MAX(l.uid)"
+	default:
+		return nil, fmt.Errorf("cannot group app traffic by %q", column)
+	}
+
+	// The alias must not name a column of app_traffic_logs, or GROUP BY
+	// would group by that instead
+	conditions, filterArgs := filter.where()
+	query := `SELECT ` + labelExpr + ` AS grp_label, ` + uidExpr + `, COUNT(DISTINCT ` + appGroupExpr + `),
+	                 COALESCE(SUM(l.bytes_in), 0), COALESCE(SUM(l.bytes_out), 0)
+	          FROM app_traffic_logs l
+	          WHERE l.timestamp >= ? AND l.timestamp <= ?` + conditions + `
+	          GROUP BY grp_label`
+
+	args := append([]interface{}{startTime, endTime}, filterArgs...)
+	rows, err := db.conn.Query(query, args...)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var groups []GroupTotals
+	for rows.Next() {
+		var g GroupTotals
+		if err := rows.Scan(&g.Label, &g.UID, &g.Apps, &g.BytesIn, &g.BytesOut); err != nil {
+			return nil, err
+		}
+		groups = append(groups, g)
+	}
+
+	return groups, rows.Err()
+}
+
+// SampleQuery selects traffic per sample, or per time bucket, for callers
+// that compute their own metrics from it. It reads app logs if Apps is set
+// and interface logs otherwise, since app traffic is not recorded per
+// interface.
+type SampleQuery struct {
+	Start       int64
+	End         int64
+	Apps        bool
+	Interface   string       // interface logs only; "" for all interfaces
+	Filter      AppLogFilter // app logs only
+	ByInterface bool         // keep interfaces apart
+	ByApp       bool         // keep apps apart
+	Bucket      int64        // sum samples into buckets of this many seconds from the epoch; 0 keeps every sample
+}
+
+// SampleTotals is the traffic of one sample or bucket of a SampleQuery.
+type SampleTotals struct {
+	Interface string // set with ByInterface
+	AppKey    string // set with ByApp
+	AppName   string // set with ByApp
+	Timestamp int64  // sample timestamp, or first second of the bucket
+	BytesIn   uint64
+	BytesOut  uint64
+	Interval  int64 // seconds the sample covers; 0 for buckets
+}
+
+// ScanSampleTotals calls fn for the traffic of each sample or bucket matching
+// q, in no particular order, without holding them all in memory.
+func (db *DB) ScanSampleTotals(q SampleQuery, fn func(SampleTotals) error) error {
+	timeExpr, intervalExpr := "l.timestamp", "MAX(l.interval_seconds)"
+	if q.Bucket > 0 {
+		timeExpr, intervalExpr = fmt.Sprintf("l.timestamp / %d * %d", q.Bucket, q.Bucket), "0"
+	}
+
+	var query string
+	args := []interface{}{q.Start, q.End}
+
+	if q.Apps {
+		// The aliases must not name columns of app_traffic_logs, or GROUP
+		// BY would group by those instead
+		appExprs := "NULL AS grp_id, NULL AS unkeyed"
+		if q.ByApp {
+			appExprs = "l.app_id AS grp_id, CASE WHEN l.app_id IS NULL THEN l.app_name END AS unkeyed"
+		}
+		conditions, filterArgs := q.Filter.where()
+		args = append(args, filterArgs...)
+
+		query = `SELECT '', COALESCE(a.app_key, 'name:' || s.unkeyed, ''), COALESCE(a.name, s.unkeyed, ''),
+		                s.ts, s.bytes_in, s.bytes_out, s.secs
+		         FROM (SELECT ` + appExprs + `, ` + timeExpr + ` AS ts,
+		                      SUM(l.bytes_in) AS bytes_in, SUM(l.bytes_out) AS bytes_out, ` + intervalExpr + ` AS secs
+		               FROM app_traffic_logs l
+		               WHERE l.timestamp >= ? AND l.timestamp <= ?` + conditions + `
+		               GROUP BY grp_id, unkeyed, ts) s
+		         LEFT JOIN apps a ON a.id = s.grp_id`
+	} else {
+		ifaceExpr := "''"
+		if q.ByInterface {
+			ifaceExpr = "l.interface"
+		}
+		conditions := ""
+		if q.Interface != "" {
+			conditions = " AND l.interface = ?"
+			args = append(args, q.Interface)
+		}
+
+		query = `SELECT ` + ifaceExpr + ` AS iface, '', '', ` + timeExpr + ` AS ts,
+		                SUM(l.bytes_in), SUM(l.bytes_out), ` + intervalExpr + `
+		         FROM traffic_logs l
+		         WHERE l.timestamp >= ? AND l.timestamp <= ?` + conditions + `
+		         GROUP BY iface, ts`
+	}
+
+	rows, err := db.conn.Query(query, args...)
+	if err != nil {
+		return err
+	}
+	defer rows.Close()
+
+	for rows.Next() {
+		var s SampleTotals
+		if err := rows.Scan(&s.Interface, &s.AppKey, &s.AppName, &s.Timestamp, &s.BytesIn, &s.BytesOut, &s.Interval); err != nil {
+			return err
+		}
+		if err := fn(s); err != nil {
+			return err
+		}
+	}
+
+	return rows.Err()
+}
+
+// AppParentTotals is the traffic of one app of a range that was rolled up to
+// one owning ancestor app, or to none.
+type AppParentTotals struct {
+	AppKey     string
+	AppName    string
+	ParentKey  string // "" if the traffic was not rolled up
+	ParentName string
+	BytesIn    uint64
+	BytesOut   uint64
+}
+
+// GetAppParentTotals sums the app traffic of a range matching filter per app
+// and owning ancestor app.
+func (db *DB) GetAppParentTotals(startTime, endTime int64, filter AppLogFilter) ([]AppParentTotals, error) {
+	conditions, filterArgs := filter.where()
+	query := `SELECT COALESCE(a.app_key, 'name:' || s.unkeyed), COALESCE(a.name, s.unkeyed),
+	                 COALESCE(p.app_key, ''), COALESCE(p.name, ''), s.bytes_in, s.bytes_out
+	          FROM (SELECT l.app_id AS grp_id, CASE WHEN l.app_id IS NULL THEN l.app_name END AS unkeyed,
+	                       l.parent_app_id AS owner_id, SUM(l.bytes_in) AS bytes_in, SUM(l.bytes_out) AS bytes_out
+	                FROM app_traffic_logs l
+	                WHERE l.timestamp >= ? AND l.timestamp <= ?` + conditions + `
+	                GROUP BY grp_id, unkeyed, owner_id) s
+	          LEFT JOIN apps a ON a.id = s.grp_id
+	          LEFT JOIN apps p ON p.id = s.owner_id`
+
+	args := append([]interface{}{startTime, endTime}, filterArgs...)
+	rows, err := db.conn.Query(query, args...)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var totals []AppParentTotals
+	for rows.Next() {
+		var t AppParentTotals
+		if err := rows.Scan(&t.AppKey, &t.AppName, &t.ParentKey, &t.ParentName, &t.BytesIn, &t.BytesOut); err != nil {
+			return nil, err
+		}
+		totals = append(totals, t)
+	}
+
+	return totals, rows.Err()
+}
+
+// SampleGap is a spacing between two consecutive interface samples.
+type SampleGap struct {
+	After  int64 // timestamp of the sample before the gap
+	Before int64 // timestamp of the sample after the gap
+}
+
+// SampleGaps describes when the interface samples of a range were taken.
+type SampleGaps struct {
+	First int64 // timestamp of the first sample; 0 if there are none
+	Last  int64 // timestamp of the last sample
+	Gaps  []SampleGap
+}
+
+// GetSampleGaps returns the first and last sample of a range and the
+// spacings between consecutive samples longer than longerThan seconds, in
+// order. Timestamps are walked in index order rather than collected, as a
+// range can hold millions of samples; SQLite's LAG() window function does the
+// same walk several times slower.
+func (db *DB) GetSampleGaps(startTime, endTime, longerThan int64) (SampleGaps, error) {
+	query := `SELECT DISTINCT timestamp FROM traffic_logs
+	          WHERE timestamp >= ? AND timestamp <= ?
+	          ORDER BY timestamp ASC`
+
+	rows, err := db.conn.Query(query, startTime, endTime)
+	if err != nil {
+		return SampleGaps{}, err
+	}
+	defer rows.Close()
+
+	var samples SampleGaps
+	for rows.Next() {
+		var ts int64
+		if err := rows.Scan(&ts); err != nil {
+			return SampleGaps{}, err
+		}
+		if samples.First == 0 {
+			samples.First = ts
+		} else if ts-samples.Last > longerThan {
+			samples.Gaps = append(samples.Gaps, SampleGap{After: samples.Last, Before: ts})
+		}
+		samples.Last = ts
+	}
+
+	return samples, rows.Err()
+}
@@ -0,0 +1,193 @@
+package db
+
+import (
+	"flag"
+	"fmt"
+	"math/rand"
+	"os"
+	"path/filepath"
+	"sync"
+	"testing"
+)
+
+// The aggregate benchmarks run against a generated database, by default of
+// two days of samples. To benchmark a bigger one, or keep it for trying the
+// CLI against, e.g.:
+//
+//	go test ./internal/db -run '^$' -bench . -args -bench-days 30 -bench-db /tmp/netmon-bench.db
+var (
+	benchDays = flag.Int("bench-days", 2, "days of samples in the generated benchmark database")
+	benchPath = flag.String("bench-db", "", "benchmark database to use, generated if it does not exist (default: a temporary file)")
+)
+
+var (
+	benchOnce sync.Once
+	benchDB   *DB
+	benchErr  error
+	benchDir  string
+)
+
+func TestMain(m *testing.M) {
+	flag.Parse()
+	code := m.Run()
+	if benchDB != nil {
+		benchDB.Close()
+	}
+	if benchDir != "" {
+		os.RemoveAll(benchDir)
+	}
+	os.Exit(code)
+}
+
+// openBenchDB opens the benchmark database, generating it on first use.
+func openBenchDB(b *testing.B) *DB {
+	benchOnce.Do(func() {
+		path := *benchPath
+		if path == "" {
+			if benchDir, benchErr = os.MkdirTemp("", "netmon-bench"); benchErr != nil {
+				return
+			}
+			path = filepath.Join(benchDir, "netmon.db")
+		}
+
+		_, statErr := os.Stat(path)
+		if benchDB, benchErr = Open(path); benchErr != nil {
+			return
+		}
+		if os.IsNotExist(statErr) {
+			benchErr = generateSamples(benchDB, *benchDays)
+		}
+	})
+	if benchErr != nil {
+		b.Fatalf("benchmark database: %v", benchErr)
+	}
+	return benchDB
+}
+
+// generateSamples fills a database with days of samples from October 2025,
+// as the service would write them: one sample per second on two interfaces,
+// the traffic of a few apps of several users and containers, some of it
+// rolled up to a parent app, namespace counters every ten seconds, and an
+// outage of a few seconds about every ten minutes.
+func generateSamples(db *DB, days int) error {
+	const startTime = 1760000000
+	endTime := int64(startTime + days*24*60*60)
+
+	tx, err := db.conn.Begin()
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+
+	apps := []string{"firefox", "curl", "ssh", "apt", "code", "git", "node", "python3"}
+	for i, name := range apps {
+		_, err := tx.Exec(`INSERT INTO apps (app_key, name, first_seen, last_seen) VALUES (?, ?, ?, ?)`,
+			"/usr/bin/"+name, name, startTime, endTime)
+		if err != nil {
+			return fmt.Errorf("insert app %d: %w", i, err)
+		}
+	}
+
+	insertInterface, err := tx.Prepare(`INSERT INTO traffic_logs (timestamp, interface, bytes_in, bytes_out, interval_seconds)
+	                                    VALUES (?, ?, ?, ?, 1)`)
+	if err != nil {
+		return err
+	}
+	insertApp, err := tx.Prepare(`INSERT INTO app_traffic_logs (timestamp, app_name, bytes_in, bytes_out, interval_seconds,
+	                                  app_id, parent_app_id, uid, username, container_id, systemd_unit, protocol, scope, family)
+	                              VALUES (?, ?, ?, ?, 1, ?, ?, ?, ?, ?, ?, ?, 'wan', 'ipv4')`)
+	if err != nil {
+		return err
+	}
+	insertNetns, err := tx.Prepare(`INSERT INTO netns_traffic_logs (timestamp, netns, container_id, interface, bytes_in, bytes_out,
+	                                    interval_seconds, netns_name, process)
+	                                VALUES (?, ?, ?, 'eth0', ?, ?, 10, '', 'sh')`)
+	if err != nil {
+		return err
+	}
+
+	users := []struct {
+		uid  int32
+		name string
+	}{{0, "root"}, {1000, "alice"}, {33, ""}, {-1, ""}}
+	containers := []string{"", "", "3f4e5d6c7b8a", "9a8b7c6d5e4f"}
+	protocols := []string{"HTTPS", "DNS", "SSH", ""}
+	random := rand.New(rand.NewSource(1))
+
+	for ts := int64(startTime); ts < endTime; ts++ {
+		if random.Intn(600) == 0 {
+			ts += 5 // the service was not running
+		}
+
+		for _, iface := range []string{"eth0", "wlan0"} {
+			if _, err := insertInterface.Exec(ts, iface, random.Intn(100000), random.Intn(25000)); err != nil {
+				return err
+			}
+		}
+
+		for i := 0; i < 3; i++ {
+			app := random.Intn(len(apps))
+			var parent interface{}
+			if app >= 5 { // git, node and python3 are spawned by code
+				parent = 5
+			}
+			user := users[random.Intn(len(users))]
+			container := containers[random.Intn(len(containers))]
+			if _, err := insertApp.Exec(ts, apps[app], random.Intn(30000), random.Intn(8000), app+1, parent,
+				user.uid, user.name, container, "", protocols[random.Intn(len(protocols))]); err != nil {
+				return err
+			}
+		}
+
+		if ts%10 == 0 {
+			for i, container := range containers[2:] {
+				if _, err := insertNetns.Exec(ts, fmt.Sprintf("net:[40265323%02d]", i), container,
+					random.Intn(100000), random.Intn(25000)); err != nil {
+					return err
+				}
+			}
+		}
+	}
+
+	return tx.Commit()
+}
+
+func BenchmarkGetAppGroupTotals(b *testing.B) {
+	db := openBenchDB(b)
+	for _, column := range []AppColumn{ColumnUser, ColumnContainer, ColumnProtocol} {
+		b.Run(string(column), func(b *testing.B) {
+			for i := 0; i < b.N; i++ {
+				if _, err := db.GetAppGroupTotals(0, 1<<40, column, AppLogFilter{}); err != nil {
+					b.Fatal(err)
+				}
+			}
+		})
+	}
+}
+
+func BenchmarkGetAppParentTotals(b *testing.B) {
+	db := openBenchDB(b)
+	for i := 0; i < b.N; i++ {
+		if _, err := db.GetAppParentTotals(0, 1<<40, AppLogFilter{}); err != nil {
+			b.Fatal(err)
+		}
+	}
+}
+
+func BenchmarkGetNetnsTotals(b *testing.B) {
+	db := openBenchDB(b)
+	for i := 0; i < b.N; i++ {
+		if _, err := db.GetNetnsTotals(0, 1<<40); err != nil {
+			b.Fatal(err)
+		}
+	}
+}
+
+func BenchmarkGetSampleGaps(b *testing.B) {
+	db := openBenchDB(b)
+	for i := 0; i < b.N; i++ {
+		if _, err := db.GetSampleGaps(0, 1<<40, 2); err != nil {
+			b.Fatal(err)
+		}
+	}
+}
@@ -0,0 +1,67 @@
+package db
+
+// Keys and names of the pseudo-apps netmon-service credits traffic to when it
+// cannot attribute it to a process, so every byte seen on interfaces is
+// accounted for.
+const (
+	UnattributedAppKey     = "pseudo:unattributed"      // no process had an active connection
+	PermissionDeniedAppKey = "pseudo:permission-denied" // sockets of processes netmon may not inspect
+
+	UnattributedApp     = "Unattributed"
+	PermissionDeniedApp = "Permission denied"
+)
+
+// App is a row of the apps dimension table: one per stable application key.
+// Details that vary between the processes of an app, such as their user or
+// cgroup, are kept on their rows of the processes table.
+type App struct {
+	ID        int64
+	Key       string
+	Name      string
+	ExePath   string
+	BundleID  string
+	FirstSeen int64
+	LastSeen  int64
+}
+
+// UpsertApp inserts an app or refreshes the details of an existing one with
+// the same key, and returns its ID. FirstSeen is only set on insert.
+func (db *DB) UpsertApp(app App) (int64, error) {
+	query := `INSERT INTO apps (app_key, name, exe_path, bundle_id, first_seen, last_seen)
+	          VALUES (?, ?, ?, ?, ?, ?)
+	          ON CONFLICT(app_key) DO UPDATE SET
+	              name = excluded.name,
+	              exe_path = excluded.exe_path,
+	              bundle_id = excluded.bundle_id,
+	              last_seen = excluded.last_seen
+	          RETURNING id`
+
+	var id int64
+	err := db.conn.QueryRow(query, app.Key, app.Name, app.ExePath, app.BundleID,
+		app.LastSeen, app.LastSeen).Scan(&id)
+	return id, err
+}
+
+// GetApps returns all known apps.
+func (db *DB) GetApps() ([]App, error) {
+	query := `SELECT id, app_key, name, exe_path, bundle_id, first_seen, last_seen
+	          FROM apps ORDER BY name ASC`
+
+	rows, err := db.conn.Query(query)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var apps []App
+	for rows.Next() {
+		var app App
+		if err := rows.Scan(&app.ID, &app.Key, &app.Name, &app.ExePath, &app.BundleID,
+			&app.FirstSeen, &app.LastSeen); err != nil {
+			return nil, err
+		}
+		apps = append(apps, app)
+	}
+
+	return apps, rows.Err()
+}
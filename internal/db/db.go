@@ -16,7 +16,8 @@ CREATE TABLE IF NOT EXISTS traffic_logs (
     timestamp INTEGER NOT NULL,
     interface TEXT NOT NULL,
     bytes_in INTEGER NOT NULL,
-    bytes_out INTEGER NOT NULL
+    bytes_out INTEGER NOT NULL,
+    interval_seconds INTEGER NOT NULL DEFAULT 1
 );
 
 CREATE TABLE IF NOT EXISTS app_traffic_logs (
@@ -24,15 +25,163 @@ CREATE TABLE IF NOT EXISTS app_traffic_logs (
     timestamp INTEGER NOT NULL,
     app_name TEXT NOT NULL,
     bytes_in INTEGER NOT NULL,
-    bytes_out INTEGER NOT NULL
+    bytes_out INTEGER NOT NULL,
+    interval_seconds INTEGER NOT NULL DEFAULT 1,
+    app_id INTEGER REFERENCES apps(id),
+    parent_app_id INTEGER REFERENCES apps(id),
+    uid INTEGER NOT NULL DEFAULT -1,
+    username TEXT NOT NULL DEFAULT '',
+    container_id TEXT NOT NULL DEFAULT '',
+    systemd_unit TEXT NOT NULL DEFAULT '',
+    protocol TEXT NOT NULL DEFAULT '',
+    scope TEXT NOT NULL DEFAULT '',
+    family TEXT NOT NULL DEFAULT ''
+);
+
+CREATE TABLE IF NOT EXISTS collector_health (
+    id INTEGER PRIMARY KEY CHECK (id = 1),
+    heartbeat INTEGER NOT NULL,
+    last_success INTEGER NOT NULL,
+    last_error TEXT NOT NULL,
+    last_error_at INTEGER NOT NULL,
+    consecutive_failures INTEGER NOT NULL,
+    pid INTEGER NOT NULL,
+    interval_seconds INTEGER NOT NULL
+);
+
+CREATE TABLE IF NOT EXISTS apps (
+    id INTEGER PRIMARY KEY AUTOINCREMENT,
+    app_key TEXT NOT NULL UNIQUE,
+    name TEXT NOT NULL,
+    exe_path TEXT NOT NULL DEFAULT '',
+    bundle_id TEXT NOT NULL DEFAULT '',
+    first_seen INTEGER NOT NULL,
+    last_seen INTEGER NOT NULL
+);
+
+CREATE TABLE IF NOT EXISTS events (
+    id INTEGER PRIMARY KEY AUTOINCREMENT,
+    timestamp INTEGER NOT NULL,
+    kind TEXT NOT NULL,
+    detail TEXT NOT NULL
+);
+
+CREATE TABLE IF NOT EXISTS listeners (
+    id INTEGER PRIMARY KEY AUTOINCREMENT,
+    app_id INTEGER NOT NULL REFERENCES apps(id),
+    transport TEXT NOT NULL,
+    address TEXT NOT NULL,
+    port INTEGER NOT NULL,
+    pid INTEGER NOT NULL,
+    first_seen INTEGER NOT NULL,
+    last_seen INTEGER NOT NULL,
+    UNIQUE(app_id, transport, address, port)
+);
+
+CREATE TABLE IF NOT EXISTS netns_traffic_logs (
+    id INTEGER PRIMARY KEY AUTOINCREMENT,
+    timestamp INTEGER NOT NULL,
+    netns TEXT NOT NULL,
+    container_id TEXT NOT NULL DEFAULT '',
+    interface TEXT NOT NULL,
+    bytes_in INTEGER NOT NULL,
+    bytes_out INTEGER NOT NULL,
+    interval_seconds INTEGER NOT NULL DEFAULT 1,
+    netns_name TEXT NOT NULL DEFAULT '',
+    process TEXT NOT NULL DEFAULT ''
+);
+
+CREATE TABLE IF NOT EXISTS processes (
+    id INTEGER PRIMARY KEY AUTOINCREMENT,
+    app_id INTEGER NOT NULL REFERENCES apps(id),
+    pid INTEGER NOT NULL,
+    start_time INTEGER NOT NULL,
+    name TEXT NOT NULL,
+    uid INTEGER NOT NULL DEFAULT -1,
+    username TEXT NOT NULL DEFAULT '',
+    ppid INTEGER NOT NULL DEFAULT 0,
+    cgroup TEXT NOT NULL DEFAULT '',
+    systemd_unit TEXT NOT NULL DEFAULT '',
+    container_id TEXT NOT NULL DEFAULT '',
+    first_seen INTEGER NOT NULL,
+    last_seen INTEGER NOT NULL,
+    exited_at INTEGER NOT NULL DEFAULT 0,
+    UNIQUE(pid, start_time)
+);
+
+CREATE TABLE IF NOT EXISTS remotes (
+    id INTEGER PRIMARY KEY AUTOINCREMENT,
+    app_id INTEGER NOT NULL REFERENCES apps(id),
+    address TEXT NOT NULL,
+    interface TEXT NOT NULL,
+    first_seen INTEGER NOT NULL,
+    last_seen INTEGER NOT NULL,
+    UNIQUE(app_id, address, interface)
 );
 
 CREATE INDEX IF NOT EXISTS idx_timestamp ON traffic_logs(timestamp);
 CREATE INDEX IF NOT EXISTS idx_interface ON traffic_logs(interface);
 CREATE INDEX IF NOT EXISTS idx_app_timestamp ON app_traffic_logs(timestamp);
 CREATE INDEX IF NOT EXISTS idx_app_name ON app_traffic_logs(app_name);
+CREATE INDEX IF NOT EXISTS idx_event_timestamp ON events(timestamp);
+CREATE INDEX IF NOT EXISTS idx_netns_timestamp ON netns_traffic_logs(timestamp);
+CREATE INDEX IF NOT EXISTS idx_process_app ON processes(app_id);
+`
+
+// postMigrationSchema creates indexes on columns added by columnMigrations.
+const postMigrationSchema = `
+CREATE INDEX IF NOT EXISTS idx_app_id ON app_traffic_logs(app_id);
+CREATE INDEX IF NOT EXISTS idx_app_username ON app_traffic_logs(username);
+CREATE INDEX IF NOT EXISTS idx_app_container ON app_traffic_logs(container_id);
 `
 
+// columnMigrations adds columns introduced after a table was first created.
+// New databases get them from schema; older ones are altered in place.
+var columnMigrations = []struct {
+	table      string
+	column     string
+	definition string
+}{
+	{"traffic_logs", "interval_seconds", "INTEGER NOT NULL DEFAULT 1"},
+	{"app_traffic_logs", "interval_seconds", "INTEGER NOT NULL DEFAULT 1"},
+	{"app_traffic_logs", "app_id", "INTEGER REFERENCES apps(id)"},
+	{"app_traffic_logs", "parent_app_id", "INTEGER REFERENCES apps(id)"},
+	{"app_traffic_logs", "uid", "INTEGER NOT NULL DEFAULT -1"},
+	{"app_traffic_logs", "username", "TEXT NOT NULL DEFAULT ''"},
+	{"app_traffic_logs", "container_id", "TEXT NOT NULL DEFAULT ''"},
+	{"app_traffic_logs", "systemd_unit", "TEXT NOT NULL DEFAULT ''"},
+	{"netns_traffic_logs", "netns_name", "TEXT NOT NULL DEFAULT ''"},
+	{"netns_traffic_logs", "process", "TEXT NOT NULL DEFAULT ''"},
+	{"app_traffic_logs", "protocol", "TEXT NOT NULL DEFAULT ''"},
+	{"app_traffic_logs", "scope", "TEXT NOT NULL DEFAULT ''"},
+	{"app_traffic_logs", "family", "TEXT NOT NULL DEFAULT ''"},
+	{"processes", "uid", "INTEGER NOT NULL DEFAULT -1"},
+	{"processes", "username", "TEXT NOT NULL DEFAULT ''"},
+	{"processes", "ppid", "INTEGER NOT NULL DEFAULT 0"},
+	{"processes", "cgroup", "TEXT NOT NULL DEFAULT ''"},
+	{"processes", "systemd_unit", "TEXT NOT NULL DEFAULT ''"},
+	{"processes", "container_id", "TEXT NOT NULL DEFAULT ''"},
+}
+
+// droppedColumns removes columns that are no longer written. The apps table
+// used to hold the details of whichever of an app's processes was seen last,
+// including a command line that may contain secrets; those details are now
+// kept per process, without the command line. Categories are no longer stored
+// either, but assigned from the current rules when stats are displayed.
+var droppedColumns = []struct {
+	table  string
+	column string
+}{
+	{"apps", "cmdline"},
+	{"apps", "uid"},
+	{"apps", "username"},
+	{"apps", "ppid"},
+	{"apps", "cgroup"},
+	{"apps", "systemd_unit"},
+	{"apps", "container_id"},
+	{"apps", "category"},
+}
+
 // DB wraps a sql.DB connection with application-specific methods.
 type DB struct {
 	conn *sql.DB
@@ -100,10 +249,70 @@ func (db *DB) Close() error {
 
 // migrate runs database schema migrations.
 func (db *DB) migrate() error {
-	_, err := db.conn.Exec(schema)
+	if _, err := db.conn.Exec(schema); err != nil {
+		return err
+	}
+
+	for _, m := range columnMigrations {
+		exists, err := db.hasColumn(m.table, m.column)
+		if err != nil {
+			return err
+		}
+		if exists {
+			continue
+		}
+		query := fmt.Sprintf("ALTER TABLE %s ADD COLUMN %s %s", m.table, m.column, m.definition)
+		if _, err := db.conn.Exec(query); err != nil {
+			return fmt.Errorf("add column %s.%s: %w", m.table, m.column, err)
+		}
+	}
+
+	for _, d := range droppedColumns {
+		exists, err := db.hasColumn(d.table, d.column)
+		if err != nil {
+			return err
+		}
+		if !exists {
+			continue
+		}
+		query := fmt.Sprintf("ALTER TABLE %s DROP COLUMN %s", d.table, d.column)
+		if _, err := db.conn.Exec(query); err != nil {
+			return fmt.Errorf("drop column %s.%s: %w", d.table, d.column, err)
+		}
+	}
+
+	_, err := db.conn.Exec(postMigrationSchema)
 	return err
 }
 
+// hasColumn reports whether table has the named column.
+func (db *DB) hasColumn(table, column string) (bool, error) {
+	rows, err := db.conn.Query(fmt.Sprintf("PRAGMA table_info(%s)", table))
+	if err != nil {
+		return false, err
+	}
+	defer rows.Close()
+
+	for rows.Next() {
+		var (
+			cid       int
+			name      string
+			colType   string
+			notNull   int
+			dfltValue sql.NullString
+			pk        int
+		)
+		if err := rows.Scan(&cid, &name, &colType, &notNull, &dfltValue, &pk); err != nil {
+			return false, err
+		}
+		if name == column {
+			return true, nil
+		}
+	}
+
+	return false, rows.Err()
+}
+
 // TrafficLog represents a single network traffic measurement.
 type TrafficLog struct {
 	ID        int64
@@ -111,27 +320,67 @@ type TrafficLog struct {
 	Interface string
 	BytesIn   uint64
 	BytesOut  uint64
+	Interval  int64 // seconds the measurement covers
 }
 
 // AppTrafficLog represents network traffic for a specific application.
 type AppTrafficLog struct {
 	ID        int64
 	Timestamp int64
+	AppID     int64  // references apps.id; 0 for rows recorded before the apps table existed
+	AppKey    string // stable grouping key, derived from the app name for legacy rows
 	AppName   string
 	BytesIn   uint64
 	BytesOut  uint64
+	Interval  int64 // seconds the measurement covers
+
+	// Owning ancestor app when traffic is rolled up the process tree; zero/empty otherwise
+	ParentAppID int64
+	ParentKey   string
+	ParentName  string
+
+	// Owner of the process that generated the traffic; -1/"" if unknown
+	UID      int32
+	Username string
+
+	// Linux only: container and systemd unit of the process; "" if none
+	ContainerID string
+	SystemdUnit string
+
+	// Protocol/service the traffic was attributed to by port, e.g. "HTTPS",
+	// and the scope ("loopback", "lan", "private", "wan") and family ("ipv4",
+	// "ipv6") of the remote addresses; "" if unclassified
+	Protocol string
+	Scope    string
+	Family   string
 }
 
 // InsertTrafficLog inserts a new traffic log entry.
 func (db *DB) InsertTrafficLog(log TrafficLog) error {
-	query := `INSERT INTO traffic_logs (timestamp, interface, bytes_in, bytes_out) VALUES (?, ?, ?, ?)`
-	_, err := db.conn.Exec(query, log.Timestamp, log.Interface, log.BytesIn, log.BytesOut)
+	query := `INSERT INTO traffic_logs (timestamp, interface, bytes_in, bytes_out, interval_seconds) VALUES (?, ?, ?, ?, ?)`
+	_, err := db.conn.Exec(query, log.Timestamp, log.Interface, log.BytesIn, log.BytesOut, intervalOrDefault(log.Interval))
 	return err
 }
 
+// nullableID stores a zero foreign key as NULL.
+func nullableID(id int64) interface{} {
+	if id <= 0 {
+		return nil
+	}
+	return id
+}
+
+// intervalOrDefault treats a missing interval as the default one-second sample.
+func intervalOrDefault(interval int64) int64 {
+	if interval < 1 {
+		return 1
+	}
+	return interval
+}
+
 // GetLogsInRange retrieves all traffic logs within a time range.
 func (db *DB) GetLogsInRange(startTime, endTime int64) ([]TrafficLog, error) {
-	query := `SELECT id, timestamp, interface, bytes_in, bytes_out 
+	query := `SELECT id, timestamp, interface, bytes_in, bytes_out, interval_seconds
 	          FROM traffic_logs 
 	          WHERE timestamp >= ? AND timestamp <= ? 
 	          ORDER BY timestamp ASC`
@@ -145,7 +394,7 @@ func (db *DB) GetLogsInRange(startTime, endTime int64) ([]TrafficLog, error) {
 	var logs []TrafficLog
 	for rows.Next() {
 		var log TrafficLog
-		if err := rows.Scan(&log.ID, &log.Timestamp, &log.Interface, &log.BytesIn, &log.BytesOut); err != nil {
+		if err := rows.Scan(&log.ID, &log.Timestamp, &log.Interface, &log.BytesIn, &log.BytesOut, &log.Interval); err != nil {
 			return nil, err
 		}
 		logs = append(logs, log)
@@ -154,21 +403,6 @@ func (db *DB) GetLogsInRange(startTime, endTime int64) ([]TrafficLog, error) {
 	return logs, rows.Err()
 }
 
-// GetLogsByInterface retrieves traffic logs grouped by interface within a time range.
-func (db *DB) GetLogsByInterface(startTime, endTime int64) (map[string][]TrafficLog, error) {
-	logs, err := db.GetLogsInRange(startTime, endTime)
-	if err != nil {
-		return nil, err
-	}
-
-	grouped := make(map[string][]TrafficLog)
-	for _, log := range logs {
-		grouped[log.Interface] = append(grouped[log.Interface], log)
-	}
-
-	return grouped, nil
-}
-
 // GetStartOfDay returns the Unix timestamp for the start of today (midnight).
 func GetStartOfDay() int64 {
 	now := time.Now()
@@ -203,48 +437,18 @@ func GetStartOfAllTime() int64 {
 
 // InsertAppTrafficLog inserts a new application traffic log entry.
 func (db *DB) InsertAppTrafficLog(log AppTrafficLog) error {
-	query := `INSERT INTO app_traffic_logs (timestamp, app_name, bytes_in, bytes_out) VALUES (?, ?, ?, ?)`
-	_, err := db.conn.Exec(query, log.Timestamp, log.AppName, log.BytesIn, log.BytesOut)
+	query := `INSERT INTO app_traffic_logs (timestamp, app_name, bytes_in, bytes_out, interval_seconds, app_id, parent_app_id,
+	                                        uid, username, container_id, systemd_unit, protocol, scope, family)
+	          VALUES (?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?)`
+	_, err := db.conn.Exec(query, log.Timestamp, log.AppName, log.BytesIn, log.BytesOut, intervalOrDefault(log.Interval),
+		nullableID(log.AppID), nullableID(log.ParentAppID), log.UID, log.Username, log.ContainerID, log.SystemdUnit,
+		log.Protocol, log.Scope, log.Family)
 	return err
 }
 
-// GetAppLogsInRange retrieves all app traffic logs within a time range.
-func (db *DB) GetAppLogsInRange(startTime, endTime int64) ([]AppTrafficLog, error) {
-	query := `SELECT id, timestamp, app_name, bytes_in, bytes_out 
-	          FROM app_traffic_logs 
-	          WHERE timestamp >= ? AND timestamp <= ? 
-	          ORDER BY timestamp ASC`
-
-	rows, err := db.conn.Query(query, startTime, endTime)
-	if err != nil {
-		return nil, err
-	}
-	defer rows.Close()
-
-	var logs []AppTrafficLog
-	for rows.Next() {
-		var log AppTrafficLog
-		if err := rows.Scan(&log.ID, &log.Timestamp, &log.AppName, &log.BytesIn, &log.BytesOut); err != nil {
-			return nil, err
-		}
-		logs = append(logs, log)
-	}
-
-	return logs, rows.Err()
-}
-
-// GetAppLogsByName retrieves traffic logs grouped by app name within a time range.
-func (db *DB) GetAppLogsByName(startTime, endTime int64) (map[string][]AppTrafficLog, error) {
-	logs, err := db.GetAppLogsInRange(startTime, endTime)
-	if err != nil {
-		return nil, err
-	}
-
-	grouped := make(map[string][]AppTrafficLog)
-	for _, log := range logs {
-		grouped[log.AppName] = append(grouped[log.AppName], log)
-	}
-
-	return grouped, nil
+// GetLatestTimestamp returns the timestamp of the most recent traffic log, or 0 if there is none.
+func (db *DB) GetLatestTimestamp() (int64, error) {
+	var ts int64
+	err := db.conn.QueryRow(`SELECT COALESCE(MAX(timestamp), 0) FROM traffic_logs`).Scan(&ts)
+	return ts, err
 }
-
@@ -0,0 +1,63 @@
+package db
+
+// Event kinds recorded by netmon-service.
+const (
+	EventSuspend  = "suspend"
+	EventResume   = "resume"
+	EventListener = "listener" // a process started listening on a new port
+)
+
+// Event records a notable occurrence observed by the collector.
+type Event struct {
+	ID        int64
+	Timestamp int64
+	Kind      string
+	Detail    string
+}
+
+// InsertEvent inserts a new event.
+func (db *DB) InsertEvent(event Event) error {
+	query := `INSERT INTO events (timestamp, kind, detail) VALUES (?, ?, ?)`
+	_, err := db.conn.Exec(query, event.Timestamp, event.Kind, event.Detail)
+	return err
+}
+
+// GetEventsInRange retrieves events of the given kinds within a time range.
+// With no kinds, all events are returned.
+func (db *DB) GetEventsInRange(startTime, endTime int64, kinds ...string) ([]Event, error) {
+	query := `SELECT id, timestamp, kind, detail FROM events WHERE timestamp >= ? AND timestamp <= ?`
+	args := []interface{}{startTime, endTime}
+	if len(kinds) > 0 {
+		query += ` AND kind IN (?` + repeatPlaceholders(len(kinds)-1) + `)`
+		for _, kind := range kinds {
+			args = append(args, kind)
+		}
+	}
+	query += ` ORDER BY timestamp ASC`
+
+	rows, err := db.conn.Query(query, args...)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var events []Event
+	for rows.Next() {
+		var event Event
+		if err := rows.Scan(&event.ID, &event.Timestamp, &event.Kind, &event.Detail); err != nil {
+			return nil, err
+		}
+		events = append(events, event)
+	}
+
+	return events, rows.Err()
+}
+
+// repeatPlaceholders returns n additional ", ?" placeholders.
+func repeatPlaceholders(n int) string {
+	s := ""
+	for i := 0; i < n; i++ {
+		s += ", ?"
+	}
+	return s
+}
@@ -0,0 +1,67 @@
+package db
+
+import (
+	"database/sql"
+	"fmt"
+	"time"
+)
+
+// Health is the collector's heartbeat record, rewritten by netmon-service on every tick.
+type Health struct {
+	Heartbeat           int64 // Unix timestamp of the last tick
+	LastSuccess         int64 // Unix timestamp of the last tick without errors
+	LastError           string
+	LastErrorAt         int64
+	ConsecutiveFailures int
+	PID                 int
+	IntervalSeconds     int64
+}
+
+// UpdateHealth stores the collector's heartbeat record, replacing the previous one.
+func (db *DB) UpdateHealth(h Health) error {
+	query := `INSERT OR REPLACE INTO collector_health
+	          (id, heartbeat, last_success, last_error, last_error_at, consecutive_failures, pid, interval_seconds)
+	          VALUES (1, ?, ?, ?, ?, ?, ?, ?)`
+	_, err := db.conn.Exec(query, h.Heartbeat, h.LastSuccess, h.LastError, h.LastErrorAt,
+		h.ConsecutiveFailures, h.PID, h.IntervalSeconds)
+	return err
+}
+
+// GetHealth returns the collector's heartbeat record. The boolean is false if
+// the collector has never written one.
+func (db *DB) GetHealth() (Health, bool, error) {
+	query := `SELECT heartbeat, last_success, last_error, last_error_at, consecutive_failures, pid, interval_seconds
+	          FROM collector_health WHERE id = 1`
+
+	var h Health
+	err := db.conn.QueryRow(query).Scan(&h.Heartbeat, &h.LastSuccess, &h.LastError, &h.LastErrorAt,
+		&h.ConsecutiveFailures, &h.PID, &h.IntervalSeconds)
+	if err == sql.ErrNoRows {
+		return Health{}, false, nil
+	}
+	if err != nil {
+		return Health{}, false, err
+	}
+	return h, true, nil
+}
+
+// Stale reports whether the heartbeat is older than a few collection intervals.
+func (h Health) Stale(now time.Time) bool {
+	threshold := 3 * h.IntervalSeconds
+	if threshold < 10 {
+		threshold = 10
+	}
+	return now.Unix()-h.Heartbeat > threshold
+}
+
+// Warning returns a human-readable problem description, or "" if the collector is healthy.
+func (h Health) Warning(now time.Time) string {
+	if h.Stale(now) {
+		age := time.Duration(now.Unix()-h.Heartbeat) * time.Second
+		return fmt.Sprintf("netmon-service last reported %s ago; data may be stale", age)
+	}
+	if h.ConsecutiveFailures > 0 {
+		return fmt.Sprintf("netmon-service is failing (%d consecutive errors): %s", h.ConsecutiveFailures, h.LastError)
+	}
+	return ""
+}
@@ -0,0 +1,59 @@
+package db
+
+// Listener is a row of the listeners inventory: a port an app has been seen
+// listening on.
+type Listener struct {
+	ID        int64
+	AppID     int64
+	AppName   string
+	Transport string
+	Address   string
+	Port      uint32
+	PID       int32 // last process seen listening
+	FirstSeen int64
+	LastSeen  int64
+}
+
+// UpsertListener records that an app is listening on a port, refreshing
+// last_seen and the PID of a known listener. The boolean is true if the
+// listener had never been seen before.
+func (db *DB) UpsertListener(l Listener) (bool, error) {
+	query := `INSERT INTO listeners (app_id, transport, address, port, pid, first_seen, last_seen)
+	          VALUES (?, ?, ?, ?, ?, ?, ?)
+	          ON CONFLICT(app_id, transport, address, port) DO UPDATE SET
+	              pid = excluded.pid,
+	              last_seen = excluded.last_seen
+	          RETURNING first_seen`
+
+	var firstSeen int64
+	err := db.conn.QueryRow(query, l.AppID, l.Transport, l.Address, l.Port, l.PID, l.LastSeen, l.LastSeen).Scan(&firstSeen)
+	return firstSeen == l.LastSeen, err
+}
+
+// GetListeners returns listeners last seen at or after since, most recently
+// opened first.
+func (db *DB) GetListeners(since int64) ([]Listener, error) {
+	query := `SELECT l.id, l.app_id, a.name, l.transport, l.address, l.port, l.pid, l.first_seen, l.last_seen
+	          FROM listeners l
+	          JOIN apps a ON a.id = l.app_id
+	          WHERE l.last_seen >= ?
+	          ORDER BY l.first_seen DESC`
+
+	rows, err := db.conn.Query(query, since)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var listeners []Listener
+	for rows.Next() {
+		var l Listener
+		if err := rows.Scan(&l.ID, &l.AppID, &l.AppName, &l.Transport, &l.Address, &l.Port, &l.PID,
+			&l.FirstSeen, &l.LastSeen); err != nil {
+			return nil, err
+		}
+		listeners = append(listeners, l)
+	}
+
+	return listeners, rows.Err()
+}
@@ -0,0 +1,69 @@
+package db
+
+// NetnsTrafficLog represents interface traffic inside a network namespace
+// other than the collector's own, e.g. a container with its own network.
+type NetnsTrafficLog struct {
+	ID          int64
+	Timestamp   int64
+	Netns       string
+	Name        string // "ip netns" name, if any
+	ContainerID string
+	Process     string // oldest process in the namespace
+	Interface   string
+	BytesIn     uint64
+	BytesOut    uint64
+	Interval    int64
+}
+
+// InsertNetnsTrafficLog inserts a new namespace traffic log entry.
+func (db *DB) InsertNetnsTrafficLog(log NetnsTrafficLog) error {
+	query := `INSERT INTO netns_traffic_logs (timestamp, netns, netns_name, container_id, process, interface,
+	                                          bytes_in, bytes_out, interval_seconds)
+	          VALUES (?, ?, ?, ?, ?, ?, ?, ?, ?)`
+	_, err := db.conn.Exec(query, log.Timestamp, log.Netns, log.Name, log.ContainerID, log.Process, log.Interface,
+		log.BytesIn, log.BytesOut, intervalOrDefault(log.Interval))
+	return err
+}
+
+// NetnsTotals is the traffic of one interface inside a network namespace
+// over a range, with the labels of its most recent log.
+type NetnsTotals struct {
+	Netns       string
+	Name        string // "ip netns" name, if any
+	ContainerID string
+	Process     string // oldest process in the namespace
+	Interface   string
+	BytesIn     uint64
+	BytesOut    uint64
+}
+
+// GetNetnsTotals sums the namespace traffic of a range per namespace and
+// interface.
+func (db *DB) GetNetnsTotals(startTime, endTime int64) ([]NetnsTotals, error) {
+	// With a single MAX, SQLite takes the bare columns from the row holding
+	// the maximum, i.e. the most recent log
+	query := `SELECT netns, interface, netns_name, container_id, process,
+	                 SUM(bytes_in), SUM(bytes_out), MAX(timestamp)
+	          FROM netns_traffic_logs
+	          WHERE timestamp >= ? AND timestamp <= ?
+	          GROUP BY netns, interface`
+
+	rows, err := db.conn.Query(query, startTime, endTime)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var totals []NetnsTotals
+	for rows.Next() {
+		var t NetnsTotals
+		var latest int64
+		if err := rows.Scan(&t.Netns, &t.Interface, &t.Name, &t.ContainerID, &t.Process,
+			&t.BytesIn, &t.BytesOut, &latest); err != nil {
+			return nil, err
+		}
+		totals = append(totals, t)
+	}
+
+	return totals, rows.Err()
+}
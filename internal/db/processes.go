@@ -0,0 +1,102 @@
+package db
+
+// Process is a row of the processes table: one process seen using the
+// network, identified by its PID and start time since PIDs are reused.
+type Process struct {
+	ID          int64
+	AppID       int64
+	PID         int32
+	StartTime   int64 // milliseconds since the epoch
+	Name        string
+	UID         int32 // -1 if unknown
+	Username    string
+	PPID        int32
+	Cgroup      string // Linux only
+	SystemdUnit string
+	ContainerID string
+	FirstSeen   int64
+	LastSeen    int64
+	ExitedAt    int64 // 0 while running
+}
+
+// UpsertProcess records that a process was seen, refreshing last_seen of a
+// known one, and returns its row ID. An exit time already recorded is kept.
+func (db *DB) UpsertProcess(p Process) (int64, error) {
+	query := `INSERT INTO processes (app_id, pid, start_time, name, uid, username, ppid, cgroup, systemd_unit, container_id,
+	                                   first_seen, last_seen, exited_at)
+	          VALUES (?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?)
+	          ON CONFLICT(pid, start_time) DO UPDATE SET
+	              app_id = excluded.app_id,
+	              name = excluded.name,
+	              uid = excluded.uid,
+	              username = excluded.username,
+	              ppid = excluded.ppid,
+	              cgroup = excluded.cgroup,
+	              systemd_unit = excluded.systemd_unit,
+	              container_id = excluded.container_id,
+	              last_seen = excluded.last_seen,
+	              exited_at = CASE WHEN processes.exited_at = 0 THEN excluded.exited_at ELSE processes.exited_at END
+	          RETURNING id`
+
+	var id int64
+	err := db.conn.QueryRow(query, p.AppID, p.PID, p.StartTime, p.Name, p.UID, p.Username, p.PPID, p.Cgroup,
+		p.SystemdUnit, p.ContainerID, p.LastSeen, p.LastSeen, p.ExitedAt).Scan(&id)
+	return id, err
+}
+
+// MarkProcessExited records when a process was found to have exited.
+func (db *DB) MarkProcessExited(id, exitedAt int64) error {
+	_, err := db.conn.Exec(`UPDATE processes SET exited_at = ? WHERE id = ? AND exited_at = 0`, exitedAt, id)
+	return err
+}
+
+// GetRunningProcesses returns the processes not yet recorded as exited.
+func (db *DB) GetRunningProcesses() ([]Process, error) {
+	query := `SELECT id, app_id, pid, start_time, name, first_seen, last_seen, exited_at
+	          FROM processes
+	          WHERE exited_at = 0`
+
+	rows, err := db.conn.Query(query)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var processes []Process
+	for rows.Next() {
+		var p Process
+		if err := rows.Scan(&p.ID, &p.AppID, &p.PID, &p.StartTime, &p.Name, &p.FirstSeen, &p.LastSeen, &p.ExitedAt); err != nil {
+			return nil, err
+		}
+		processes = append(processes, p)
+	}
+
+	return processes, rows.Err()
+}
+
+// GetAppUsernames returns the names of the users that processes of an app ran
+// as, most recently seen first.
+func (db *DB) GetAppUsernames(appID int64) ([]string, error) {
+	query := `SELECT username
+	          FROM processes
+	          WHERE app_id = ? AND username != ''
+	          GROUP BY username
+	          ORDER BY MAX(last_seen) DESC`
+
+	rows, err := db.conn.Query(query, appID)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var usernames []string
+	for rows.Next() {
+		var username string
+		if err := rows.Scan(&username); err != nil {
+			return nil, err
+		}
+		usernames = append(usernames, username)
+	}
+
+	return usernames, rows.Err()
+}
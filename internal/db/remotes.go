@@ -0,0 +1,85 @@
+package db
+
+// Remote is a row of the remotes table: a host an app has been seen
+// connected to, and the local interface the connection went through.
+type Remote struct {
+	ID        int64
+	AppID     int64
+	Address   string
+	Interface string // "" if no interface held the local address
+	FirstSeen int64
+	LastSeen  int64
+}
+
+// UpsertRemote records that an app is connected to a host, refreshing
+// last_seen of a known one.
+func (db *DB) UpsertRemote(r Remote) error {
+	query := `INSERT INTO remotes (app_id, address, interface, first_seen, last_seen)
+	          VALUES (?, ?, ?, ?, ?)
+	          ON CONFLICT(app_id, address, interface) DO UPDATE SET
+	              last_seen = excluded.last_seen`
+
+	_, err := db.conn.Exec(query, r.AppID, r.Address, r.Interface, r.LastSeen, r.LastSeen)
+	return err
+}
+
+// GetAppRemotes returns the hosts an app was connected to between startTime
+// and endTime, most recently seen first.
+func (db *DB) GetAppRemotes(appKey string, startTime, endTime int64) ([]Remote, error) {
+	query := `SELECT r.id, r.app_id, r.address, r.interface, r.first_seen, r.last_seen
+	          FROM remotes r
+	          JOIN apps a ON a.id = r.app_id
+	          WHERE a.app_key = ? AND r.last_seen >= ? AND r.first_seen <= ?
+	          ORDER BY r.last_seen DESC, r.address`
+
+	rows, err := db.conn.Query(query, appKey, startTime, endTime)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var remotes []Remote
+	for rows.Next() {
+		var r Remote
+		if err := rows.Scan(&r.ID, &r.AppID, &r.Address, &r.Interface, &r.FirstSeen, &r.LastSeen); err != nil {
+			return nil, err
+		}
+		remotes = append(remotes, r)
+	}
+
+	return remotes, rows.Err()
+}
+
+// InterfaceApp is an app seen connected through an interface.
+type InterfaceApp struct {
+	AppKey  string
+	AppName string
+	Hosts   int // distinct remote hosts reached through the interface
+}
+
+// GetInterfaceApps returns the apps with connections through an interface
+// between startTime and endTime.
+func (db *DB) GetInterfaceApps(iface string, startTime, endTime int64) ([]InterfaceApp, error) {
+	query := `SELECT a.app_key, a.name, COUNT(DISTINCT r.address)
+	          FROM remotes r
+	          JOIN apps a ON a.id = r.app_id
+	          WHERE r.interface = ? AND r.last_seen >= ? AND r.first_seen <= ?
+	          GROUP BY a.id`
+
+	rows, err := db.conn.Query(query, iface, startTime, endTime)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var apps []InterfaceApp
+	for rows.Next() {
+		var a InterfaceApp
+		if err := rows.Scan(&a.AppKey, &a.AppName, &a.Hosts); err != nil {
+			return nil, err
+		}
+		apps = append(apps, a)
+	}
+
+	return apps, rows.Err()
+}
@@ -0,0 +1,47 @@
+package protocols
+
+// defaultPorts are loaded before any user entries, which may override them.
+var defaultPorts = []Entry{
+	// Web
+	{Port: 80, Transport: TCP, Service: "HTTP"},
+	{Port: 443, Transport: TCP, Service: "HTTPS"},
+	{Port: 443, Transport: UDP, Service: "QUIC"},
+	{Port: 8080, Transport: TCP, Service: "HTTP"},
+	{Port: 8443, Transport: TCP, Service: "HTTPS"},
+
+	// Name resolution and time
+	{Port: 53, Service: "DNS"},
+	{Port: 853, Service: "DNS over TLS"},
+	{Port: 5353, Transport: UDP, Service: "mDNS"},
+	{Port: 123, Transport: UDP, Service: "NTP"},
+
+	// Remote access and file transfer
+	{Port: 22, Transport: TCP, Service: "SSH"},
+	{Port: 3389, Service: "RDP"},
+	{Port: 5900, Transport: TCP, Service: "VNC"},
+	{Port: 445, Transport: TCP, Service: "SMB"},
+	{Port: 2049, Service: "NFS"},
+	{Port: 548, Transport: TCP, Service: "AFP"},
+
+	// Mail
+	{Port: 25, Transport: TCP, Service: "SMTP"},
+	{Port: 465, Transport: TCP, Service: "SMTP"},
+	{Port: 587, Transport: TCP, Service: "SMTP"},
+	{Port: 993, Transport: TCP, Service: "IMAP"},
+	{Port: 995, Transport: TCP, Service: "POP3"},
+
+	// VPN and real-time media
+	{Port: 51820, Transport: UDP, Service: "WireGuard"},
+	{Port: 1194, Service: "OpenVPN"},
+	{Port: 500, Transport: UDP, Service: "IPsec"},
+	{Port: 4500, Transport: UDP, Service: "IPsec"},
+	{Port: 3478, Service: "STUN/TURN"},
+	{Port: 19302, Transport: UDP, Service: "STUN/TURN"},
+
+	// Databases and developer services
+	{Port: 5432, Transport: TCP, Service: "PostgreSQL"},
+	{Port: 3306, Transport: TCP, Service: "MySQL"},
+	{Port: 6379, Transport: TCP, Service: "Redis"},
+	{Port: 27017, Transport: TCP, Service: "MongoDB"},
+	{Port: 9418, Transport: TCP, Service: "Git"},
+}
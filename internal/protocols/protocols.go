@@ -0,0 +1,115 @@
+package protocols
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+)
+
+// Transports a port entry can apply to.
+const (
+	TCP = "tcp"
+	UDP = "udp"
+)
+
+// Entry maps a port to the name of the service or protocol using it.
+type Entry struct {
+	Port      uint32 `json:"port"`
+	Transport string `json:"transport,omitempty"` // "tcp", "udp", or empty for both
+	Service   string `json:"service"`
+}
+
+// File is the on-disk format of a port map file.
+type File struct {
+	Ports []Entry `json:"ports"`
+}
+
+// Map classifies connections by port.
+type Map struct {
+	services map[string]string // keyed by transport and port
+}
+
+// DefaultPath returns the default port map location (~/.netmon/ports.json).
+func DefaultPath() string {
+	home, err := os.UserHomeDir()
+	if err != nil {
+		return "./ports.json"
+	}
+	return filepath.Join(home, ".netmon", "ports.json")
+}
+
+// Load reads a port map file on top of the built-in defaults, so user entries
+// take precedence. A missing file yields the defaults alone.
+func Load(path string) (*Map, error) {
+	var file File
+
+	data, err := os.ReadFile(path)
+	if err != nil && !os.IsNotExist(err) {
+		return nil, fmt.Errorf("read port map %s: %w", path, err)
+	}
+	if err == nil {
+		if err := json.Unmarshal(data, &file); err != nil {
+			return nil, fmt.Errorf("parse port map %s: %w", path, err)
+		}
+	}
+
+	return New(append(append([]Entry{}, defaultPorts...), file.Ports...))
+}
+
+// New builds a port map. Later entries override earlier ones for the same port.
+func New(entries []Entry) (*Map, error) {
+	m := &Map{services: make(map[string]string, len(entries))}
+	for i, entry := range entries {
+		if entry.Port == 0 || entry.Port > 65535 {
+			return nil, fmt.Errorf("port entry %d: invalid port %d", i+1, entry.Port)
+		}
+		if entry.Service == "" {
+			return nil, fmt.Errorf("port entry %d: no service", i+1)
+		}
+
+		switch transport := strings.ToLower(entry.Transport); transport {
+		case TCP, UDP:
+			m.services[key(transport, entry.Port)] = entry.Service
+		case "":
+			m.services[key(TCP, entry.Port)] = entry.Service
+			m.services[key(UDP, entry.Port)] = entry.Service
+		default:
+			return nil, fmt.Errorf("port entry %d: unknown transport %q", i+1, entry.Transport)
+		}
+	}
+	return m, nil
+}
+
+// Classify returns the service an outgoing connection belongs to. The remote
+// port is tried first, as it identifies the service; the local port is tried
+// if the remote one is unknown. Connections on unknown ports are classified
+// as "Other TCP" or "Other UDP".
+func (m *Map) Classify(transport string, localPort, remotePort uint32) string {
+	return m.lookup(transport, remotePort, localPort)
+}
+
+// ClassifyServer returns the service a connection accepted by a local server
+// belongs to, like Classify but trying the local port first: the client's
+// ephemeral port may happen to be a known one (e.g. UDP 51820).
+func (m *Map) ClassifyServer(transport string, localPort, remotePort uint32) string {
+	return m.lookup(transport, localPort, remotePort)
+}
+
+// lookup returns the service of the first known port, or "Other TCP"/"Other UDP".
+func (m *Map) lookup(transport string, ports ...uint32) string {
+	if m != nil {
+		for _, port := range ports {
+			if service, ok := m.services[key(transport, port)]; ok && port != 0 {
+				return service
+			}
+		}
+	}
+	return "Other " + strings.ToUpper(transport)
+}
+
+// key builds the lookup key for a transport and port.
+func key(transport string, port uint32) string {
+	return fmt.Sprintf("%s/%d", transport, port)
+}
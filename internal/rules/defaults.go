@@ -0,0 +1,24 @@
+package rules
+
+// defaultRules are applied after any user rules. They only assign categories
+// and fold a few well-known multi-process programs into one app.
+var defaultRules = []Rule{
+	// Browsers
+	{Name: `(?i)^(google chrome|chromium|firefox|safari|microsoft edge|brave browser|arc|opera|vivaldi)`, Category: "Browsers"},
+	{Exe: `(?i)/(chrome|chromium|firefox|msedge|brave)$`, Category: "Browsers"},
+
+	// Editors and language servers
+	{Exe: `(?i)(gopls|rust-analyzer|clangd|typescript-language-server|pyright|jdtls)$`, App: "Language Servers", Category: "Dev Tools"},
+	{Name: `(?i)^(code|cursor|android studio|intellij idea|goland|pycharm|xcode|zed|sublime text)`, Category: "Dev Tools"},
+	{Exe: `(?i)/(git|git-remote-https|ssh|go|cargo|npm|node|docker|gradle|java)$`, Category: "Dev Tools"},
+
+	// Sync and backup
+	{Name: `(?i)^(dropbox|onedrive|google drive|icloud|syncthing|backblaze|nextcloud)`, Category: "Sync"},
+	{Exe: `(?i)/(bird|cloudd|rclone|rsync|syncthing)$`, Category: "Sync"},
+
+	// Communication
+	{Name: `(?i)^(slack|discord|zoom|microsoft teams|telegram|signal|whatsapp|messages|mail)`, Category: "Communication"},
+
+	// Scripts: group interpreters by the script they run
+	{Cmdline: `^\S*python[0-9.]*\s+(?:-\S+\s+)*\S*?([^/\s]+)\.py\b`, App: "${1}.py", Category: "Scripts"},
+}
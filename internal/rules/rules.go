@@ -0,0 +1,186 @@
+package rules
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"regexp"
+)
+
+// Rule maps processes to a canonical app name and/or category. Every pattern
+// that is set must match; unset patterns are ignored.
+type Rule struct {
+	Name     string `json:"name,omitempty"`    // regexp matched against the app's display name
+	Exe      string `json:"exe,omitempty"`     // regexp matched against the executable path
+	Cmdline  string `json:"cmdline,omitempty"` // regexp matched against the full command line
+	App      string `json:"app,omitempty"`     // canonical app name; may reference capture groups, e.g. "${1}"
+	Category string `json:"category,omitempty"`
+	Owner    bool   `json:"owner,omitempty"` // matching processes own the traffic of processes they spawn
+
+	name, exe, cmdline *regexp.Regexp
+}
+
+// File is the on-disk format of a rules file.
+type File struct {
+	Rules []Rule `json:"rules"`
+}
+
+// Subject is the process information rules are matched against.
+type Subject struct {
+	Name    string
+	ExePath string
+	Cmdline string
+}
+
+// Result is the outcome of matching a subject against a rule set.
+type Result struct {
+	App      string // "" if no rule renames the app
+	Category string // "" if no rule assigns a category
+	Owner    bool   // true if any matching rule marks the app as an owner
+}
+
+// Set is an ordered list of rules. For both the app name and the category,
+// the first matching rule that sets it wins.
+type Set struct {
+	rules []Rule
+}
+
+// DefaultPath returns the default rules file location (~/.netmon/rules.json).
+func DefaultPath() string {
+	home, err := os.UserHomeDir()
+	if err != nil {
+		return "./rules.json"
+	}
+	return filepath.Join(home, ".netmon", "rules.json")
+}
+
+// Load reads a rules file and appends the built-in defaults after it, so user
+// rules take precedence. A missing file yields the defaults alone.
+func Load(path string) (*Set, error) {
+	var file File
+
+	data, err := os.ReadFile(path)
+	if err != nil && !os.IsNotExist(err) {
+		return nil, fmt.Errorf("read rules %s: %w", path, err)
+	}
+	if err == nil {
+		if err := json.Unmarshal(data, &file); err != nil {
+			return nil, fmt.Errorf("parse rules %s: %w", path, err)
+		}
+	}
+
+	return New(append(file.Rules, defaultRules...))
+}
+
+// New compiles rules into a set.
+func New(rules []Rule) (*Set, error) {
+	compiled := make([]Rule, 0, len(rules))
+	for i, rule := range rules {
+		var err error
+		if rule.name, err = compile(rule.Name); err != nil {
+			return nil, fmt.Errorf("rule %d: name: %w", i+1, err)
+		}
+		if rule.exe, err = compile(rule.Exe); err != nil {
+			return nil, fmt.Errorf("rule %d: exe: %w", i+1, err)
+		}
+		if rule.cmdline, err = compile(rule.Cmdline); err != nil {
+			return nil, fmt.Errorf("rule %d: cmdline: %w", i+1, err)
+		}
+		if rule.name == nil && rule.exe == nil && rule.cmdline == nil {
+			return nil, fmt.Errorf("rule %d: no name, exe or cmdline pattern", i+1)
+		}
+		compiled = append(compiled, rule)
+	}
+	return &Set{rules: compiled}, nil
+}
+
+// WithoutCmdline returns the rules that match no command line, for matching
+// apps whose command line at the time is not known.
+func (s *Set) WithoutCmdline() *Set {
+	if s == nil {
+		return nil
+	}
+	var kept []Rule
+	for _, rule := range s.rules {
+		if rule.cmdline == nil {
+			kept = append(kept, rule)
+		}
+	}
+	return &Set{rules: kept}
+}
+
+// HasOwners reports whether any rule marks apps as owners of their child processes.
+func (s *Set) HasOwners() bool {
+	if s == nil {
+		return false
+	}
+	for _, rule := range s.rules {
+		if rule.Owner {
+			return true
+		}
+	}
+	return false
+}
+
+// compile compiles a pattern, returning nil for an empty one.
+func compile(pattern string) (*regexp.Regexp, error) {
+	if pattern == "" {
+		return nil, nil
+	}
+	return regexp.Compile(pattern)
+}
+
+// Match applies the rule set to a subject.
+func (s *Set) Match(subject Subject) Result {
+	var result Result
+	if s == nil {
+		return result
+	}
+
+	for _, rule := range s.rules {
+		expand, ok := rule.match(subject)
+		if !ok {
+			continue
+		}
+
+		if result.App == "" && rule.App != "" {
+			result.App = expand(rule.App)
+		}
+		if result.Category == "" && rule.Category != "" {
+			result.Category = rule.Category
+		}
+		result.Owner = result.Owner || rule.Owner
+	}
+
+	return result
+}
+
+// match reports whether every pattern of the rule matches. The returned
+// function expands capture-group references from the most specific pattern
+// (cmdline, then exe, then name).
+func (r Rule) match(subject Subject) (func(string) string, bool) {
+	type target struct {
+		re    *regexp.Regexp
+		value string
+	}
+	targets := []target{{r.name, subject.Name}, {r.exe, subject.ExePath}, {r.cmdline, subject.Cmdline}}
+
+	var last target
+	var submatches []int
+	for _, t := range targets {
+		if t.re == nil {
+			continue
+		}
+		m := t.re.FindStringSubmatchIndex(t.value)
+		if m == nil {
+			return nil, false
+		}
+		last, submatches = t, m
+	}
+
+	expand := func(template string) string {
+		return string(last.re.ExpandString(nil, template, last.value, submatches))
+	}
+	return expand, true
+}
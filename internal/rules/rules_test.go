@@ -0,0 +1,103 @@
+package rules
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+func TestMatchPrecedence(t *testing.T) {
+	set, err := New([]Rule{
+		{Exe: `/electron-helper$`, App: "Electron Apps"},
+		{Name: `^electron`, App: "Electron", Category: "Dev Tools"},
+		{Name: `(?i)^slack`, Category: "Chat", Owner: true},
+		{Name: `(?i)^slack`, Exe: `/usr/bin/`, Category: "Communication"},
+		{Cmdline: `python3 .*/(\w+)\.py`, Exe: `/(python3)$`, Name: `^(py)`, App: "${1}.py"},
+		{Exe: `/python3$`, App: "Python", Category: "Scripts"},
+		{Name: `(?i)^slack`, Owner: false},
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	tests := []struct {
+		name    string
+		subject Subject
+		want    Result
+	}{
+		{
+			name:    "first rule setting the app wins; a later one still sets the category",
+			subject: Subject{Name: "electron", ExePath: "/opt/app/electron-helper"},
+			want:    Result{App: "Electron Apps", Category: "Dev Tools"},
+		},
+		{
+			name:    "first rule setting the category wins",
+			subject: Subject{Name: "Slack", ExePath: "/usr/bin/slack"},
+			want:    Result{Category: "Chat", Owner: true},
+		},
+		{
+			name:    "rules that do not match are skipped",
+			subject: Subject{Name: "electron-app", ExePath: "/opt/electron"},
+			want:    Result{App: "Electron", Category: "Dev Tools"},
+		},
+		{
+			name:    "captures expand from the most specific pattern",
+			subject: Subject{Name: "python3", ExePath: "/usr/bin/python3", Cmdline: "python3 /srv/app/worker.py --debug"},
+			want:    Result{App: "worker.py", Category: "Scripts"},
+		},
+		{
+			name:    "every pattern of a rule must match",
+			subject: Subject{Name: "python3", ExePath: "/usr/bin/python3"},
+			want:    Result{App: "Python", Category: "Scripts"},
+		},
+		{
+			name:    "no matching rule",
+			subject: Subject{Name: "curl", ExePath: "/usr/bin/curl"},
+			want:    Result{},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := set.Match(tt.subject); got != tt.want {
+				t.Errorf("Match(%+v) = %+v, want %+v", tt.subject, got, tt.want)
+			}
+		})
+	}
+
+	t.Run("without command line rules", func(t *testing.T) {
+		subject := Subject{Name: "python3", ExePath: "/usr/bin/python3", Cmdline: "python3 /srv/app/worker.py"}
+		want := Result{App: "Python", Category: "Scripts"}
+		if got := set.WithoutCmdline().Match(subject); got != want {
+			t.Errorf("Match(%+v) = %+v, want %+v", subject, got, want)
+		}
+	})
+}
+
+func TestLoadUserRulesBeforeDefaults(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "rules.json")
+	err := os.WriteFile(path, []byte(`{"rules": [{"name": "(?i)^firefox", "app": "Web", "category": "Work"}]}`), 0644)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	tests := []struct {
+		path    string
+		subject Subject
+		want    Result
+	}{
+		{path, Subject{Name: "Firefox", ExePath: "/usr/lib/firefox/firefox"}, Result{App: "Web", Category: "Work"}},
+		{path, Subject{Name: "chromium", ExePath: "/usr/bin/chromium"}, Result{Category: "Browsers"}},
+		{filepath.Join(t.TempDir(), "missing.json"), Subject{Name: "Firefox", ExePath: "/usr/lib/firefox/firefox"}, Result{Category: "Browsers"}},
+	}
+
+	for _, tt := range tests {
+		set, err := Load(tt.path)
+		if err != nil {
+			t.Fatalf("Load(%s): %v", tt.path, err)
+		}
+		if got := set.Match(tt.subject); got != tt.want {
+			t.Errorf("Match(%+v) = %+v, want %+v", tt.subject, got, tt.want)
+		}
+	}
+}
@@ -0,0 +1,150 @@
+package scope
+
+import (
+	"fmt"
+	"net"
+	"strings"
+	"sync"
+	"time"
+)
+
+// Scopes a remote address can fall into.
+const (
+	Loopback = "loopback"
+	LAN      = "lan"     // local networks, link-local and multicast
+	Private  = "private" // private ranges not on a local network, e.g. over a VPN
+	WAN      = "wan"
+)
+
+// Address families.
+const (
+	IPv4 = "ipv4"
+	IPv6 = "ipv6"
+)
+
+// localRefreshInterval is how often the subnets of the host's interfaces are
+// re-read when no local networks are configured.
+const localRefreshInterval = time.Minute
+
+var privateNets = mustParseCIDRs("10.0.0.0/8,172.16.0.0/12,192.168.0.0/16,100.64.0.0/10,fc00::/7")
+
+var onLinkNets = mustParseCIDRs("169.254.0.0/16,224.0.0.0/4,255.255.255.255/32,fe80::/10,ff00::/8")
+
+// Classifier sorts addresses into scopes.
+type Classifier struct {
+	mu        sync.Mutex
+	local     []*net.IPNet
+	auto      bool // local is derived from the host's interfaces
+	refreshed time.Time
+}
+
+// New creates a classifier treating the given networks as local. With none,
+// the subnets of the host's interfaces are used and kept up to date.
+func New(local []*net.IPNet) *Classifier {
+	return &Classifier{local: local, auto: len(local) == 0}
+}
+
+// ParseCIDRs parses a comma-separated list of networks, e.g. "192.168.1.0/24,fd00::/8".
+func ParseCIDRs(list string) ([]*net.IPNet, error) {
+	var nets []*net.IPNet
+	for _, cidr := range strings.Split(list, ",") {
+		cidr = strings.TrimSpace(cidr)
+		if cidr == "" {
+			continue
+		}
+		_, network, err := net.ParseCIDR(cidr)
+		if err != nil {
+			return nil, fmt.Errorf("parse CIDR %q: %w", cidr, err)
+		}
+		nets = append(nets, network)
+	}
+	return nets, nil
+}
+
+// mustParseCIDRs is ParseCIDRs for built-in lists.
+func mustParseCIDRs(list string) []*net.IPNet {
+	nets, err := ParseCIDRs(list)
+	if err != nil {
+		panic(err)
+	}
+	return nets
+}
+
+// Family returns the address family of ip, or "" if it is not an IP address.
+// IPv4-mapped IPv6 addresses count as IPv4.
+func Family(ip string) string {
+	parsed := net.ParseIP(ip)
+	switch {
+	case parsed == nil:
+		return ""
+	case parsed.To4() != nil:
+		return IPv4
+	default:
+		return IPv6
+	}
+}
+
+// Classify returns the scope of a remote address, or "" if it is not an IP
+// address (e.g. an unconnected socket).
+func (c *Classifier) Classify(ip string) string {
+	parsed := net.ParseIP(ip)
+	if parsed == nil || parsed.IsUnspecified() {
+		return ""
+	}
+
+	switch {
+	case parsed.IsLoopback():
+		return Loopback
+	case contains(c.localNets(), parsed), contains(onLinkNets, parsed):
+		return LAN
+	case contains(privateNets, parsed):
+		return Private
+	default:
+		return WAN
+	}
+}
+
+// localNets returns the networks considered local, re-reading the host's
+// interfaces periodically if none were configured. A nil classifier has none.
+func (c *Classifier) localNets() []*net.IPNet {
+	if c == nil {
+		return nil
+	}
+
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	if c.auto && time.Since(c.refreshed) > localRefreshInterval {
+		c.local = interfaceNets()
+		c.refreshed = time.Now()
+	}
+	return c.local
+}
+
+// interfaceNets returns the subnets of the host's non-loopback interfaces.
+func interfaceNets() []*net.IPNet {
+	addrs, err := net.InterfaceAddrs()
+	if err != nil {
+		return nil
+	}
+
+	var nets []*net.IPNet
+	for _, addr := range addrs {
+		network, ok := addr.(*net.IPNet)
+		if !ok || network.IP.IsLoopback() {
+			continue
+		}
+		nets = append(nets, &net.IPNet{IP: network.IP.Mask(network.Mask), Mask: network.Mask})
+	}
+	return nets
+}
+
+// contains reports whether any of the networks contains ip.
+func contains(nets []*net.IPNet, ip net.IP) bool {
+	for _, network := range nets {
+		if network.Contains(ip) {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,157 @@
+package service
+
+import (
+	"bytes"
+	"fmt"
+	"html"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"regexp"
+	"strconv"
+	"strings"
+)
+
+// launchd manages per-user LaunchAgents on macOS.
+type launchd struct {
+	dir string
+}
+
+func (l *launchd) Kind() string {
+	return "launchd"
+}
+
+func (l *launchd) ConfigPath(spec Spec) string {
+	return filepath.Join(l.dir, spec.Label+".plist")
+}
+
+func (l *launchd) Render(spec Spec) []byte {
+	var args strings.Builder
+	for _, arg := range append([]string{spec.Program}, spec.Args...) {
+		fmt.Fprintf(&args, "        <string>%s</string>\n", html.EscapeString(arg))
+	}
+
+	// Menu bar apps need to run in the user's GUI session, so we use
+	// LimitLoadToSessionType with Aqua to ensure GUI access
+	session := ""
+	if spec.GUISession {
+		session = `
+    <key>LimitLoadToSessionType</key>
+    <string>Aqua</string>
+    `
+	}
+
+	return []byte(fmt.Sprintf(`<?xml version="1.0" encoding="UTF-8"?>
+<!DOCTYPE plist PUBLIC "-//Apple//DTD PLIST 1.0//EN" "http://www.apple.com/DTDs/PropertyList-1.0.dtd">
+<plist version="1.0">
+<dict>
+    <key>Label</key>
+    <string>%s</string>
+
+    <key>ProgramArguments</key>
+    <array>
+%s    </array>
+
+    <key>RunAtLoad</key>
+    <true/>
+
+    <key>KeepAlive</key>
+    <true/>
+    %s
+    <key>StandardOutPath</key>
+    <string>%s</string>
+
+    <key>StandardErrorPath</key>
+    <string>%s</string>
+
+    <key>EnvironmentVariables</key>
+    <dict>
+        <key>PATH</key>
+        <string>/usr/local/bin:/usr/bin:/bin:/usr/sbin:/sbin</string>
+    </dict>
+</dict>
+</plist>
+`, spec.Label, args.String(), session, spec.StdoutPath, spec.StderrPath))
+}
+
+func (l *launchd) Install(spec Spec) error {
+	path := l.ConfigPath(spec)
+	if fileExists(path) {
+		// Unload existing job, ignore errors as it might not be loaded
+		exec.Command("launchctl", "unload", path).Run()
+	}
+
+	if err := writeConfig(path, l.Render(spec)); err != nil {
+		return err
+	}
+
+	return l.Start(spec)
+}
+
+var launchdPIDPattern = regexp.MustCompile(`"PID"\s*=\s*(\d+);`)
+
+func (l *launchd) Status(spec Spec) (Status, error) {
+	status := Status{ConfigPath: l.ConfigPath(spec)}
+	status.Installed = fileExists(status.ConfigPath)
+
+	output, err := exec.Command("launchctl", "list", spec.Label).CombinedOutput()
+	if err != nil {
+		// launchctl exits non-zero when the job is not loaded
+		return status, nil
+	}
+
+	if m := launchdPIDPattern.FindSubmatch(output); m != nil {
+		status.PID, _ = strconv.Atoi(string(m[1]))
+		status.Running = status.PID > 0
+	}
+
+	return status, nil
+}
+
+// Start loads the job. With KeepAlive set, loading is what starts it.
+func (l *launchd) Start(spec Spec) error {
+	path := l.ConfigPath(spec)
+	if !fileExists(path) {
+		return fmt.Errorf("%s is not installed (missing %s)", spec.Label, path)
+	}
+	return launchctl("load", "-w", path)
+}
+
+// Stop unloads the job. A plain "launchctl stop" would be undone by KeepAlive.
+func (l *launchd) Stop(spec Spec) error {
+	path := l.ConfigPath(spec)
+	if !fileExists(path) {
+		return fmt.Errorf("%s is not installed (missing %s)", spec.Label, path)
+	}
+	return launchctl("unload", path)
+}
+
+func (l *launchd) Restart(spec Spec) error {
+	// Ignore stop errors, the job might not be loaded
+	l.Stop(spec)
+	return l.Start(spec)
+}
+
+func (l *launchd) Uninstall(spec Spec) error {
+	path := l.ConfigPath(spec)
+	if !fileExists(path) {
+		return nil
+	}
+
+	// Ignore errors, the job might not be loaded
+	exec.Command("launchctl", "unload", path).Run()
+
+	if err := os.Remove(path); err != nil {
+		return fmt.Errorf("remove %s: %w", path, err)
+	}
+	return nil
+}
+
+// launchctl runs a launchctl command and includes its output in any error.
+func launchctl(args ...string) error {
+	output, err := exec.Command("launchctl", args...).CombinedOutput()
+	if err != nil {
+		return fmt.Errorf("launchctl %s: %w\n%s", strings.Join(args, " "), err, bytes.TrimSpace(output))
+	}
+	return nil
+}
@@ -0,0 +1,109 @@
+package service
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"runtime"
+)
+
+// Spec describes a background job registered with the platform service manager.
+type Spec struct {
+	Label      string   // launchd label, also used to derive the systemd unit name
+	Program    string   // absolute path to the executable
+	Args       []string // extra command-line arguments
+	StdoutPath string
+	StderrPath string
+	GUISession bool // job needs access to the user's graphical session
+}
+
+// Status reports the state of an installed job.
+type Status struct {
+	Installed  bool
+	Running    bool
+	PID        int
+	ConfigPath string
+}
+
+// Manager drives the platform service manager (launchd or systemd).
+type Manager interface {
+	// Kind returns the name of the underlying service manager.
+	Kind() string
+	// ConfigPath returns the path of the job definition file for spec.
+	ConfigPath(spec Spec) string
+	// Render returns the job definition file contents for spec.
+	Render(spec Spec) []byte
+	// Install writes the job definition and starts the job.
+	Install(spec Spec) error
+	// Status returns the current state of the job.
+	Status(spec Spec) (Status, error)
+	Start(spec Spec) error
+	Stop(spec Spec) error
+	Restart(spec Spec) error
+	// Uninstall stops the job and removes its definition file.
+	Uninstall(spec Spec) error
+}
+
+const (
+	// ServiceLabel identifies the collector daemon.
+	ServiceLabel = "com.netmon.service"
+	// MenuLabel identifies the menu bar app.
+	MenuLabel = "com.netmon.menu"
+)
+
+// ServiceSpec returns the job definition for netmon-service.
+func ServiceSpec(program string, args ...string) Spec {
+	return Spec{
+		Label:      ServiceLabel,
+		Program:    program,
+		Args:       args,
+		StdoutPath: "/tmp/netmon-service.log",
+		StderrPath: "/tmp/netmon-service.error.log",
+	}
+}
+
+// MenuSpec returns the job definition for netmon-menu.
+func MenuSpec(program string, args ...string) Spec {
+	return Spec{
+		Label:      MenuLabel,
+		Program:    program,
+		Args:       args,
+		StdoutPath: "/tmp/netmon-menu.log",
+		StderrPath: "/tmp/netmon-menu.error.log",
+		GUISession: true,
+	}
+}
+
+// Detect returns the service manager for the current platform.
+func Detect() (Manager, error) {
+	home, err := os.UserHomeDir()
+	if err != nil {
+		return nil, fmt.Errorf("determine home directory: %w", err)
+	}
+
+	switch runtime.GOOS {
+	case "darwin":
+		return &launchd{dir: filepath.Join(home, "Library", "LaunchAgents")}, nil
+	case "linux":
+		return &systemd{dir: filepath.Join(home, ".config", "systemd", "user")}, nil
+	default:
+		return nil, fmt.Errorf("no supported service manager on %s", runtime.GOOS)
+	}
+}
+
+// writeConfig writes a job definition file, creating its directory if needed.
+func writeConfig(path string, content []byte) error {
+	if err := os.MkdirAll(filepath.Dir(path), 0755); err != nil {
+		return fmt.Errorf("create %s: %w", filepath.Dir(path), err)
+	}
+	if err := os.WriteFile(path, content, 0644); err != nil {
+		return fmt.Errorf("write %s: %w", path, err)
+	}
+	return nil
+}
+
+// fileExists reports whether path exists.
+func fileExists(path string) bool {
+	_, err := os.Stat(path)
+	return err == nil
+}
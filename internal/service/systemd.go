@@ -0,0 +1,128 @@
+package service
+
+import (
+	"bytes"
+	"fmt"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strconv"
+	"strings"
+)
+
+// systemd manages per-user units on Linux.
+type systemd struct {
+	dir string
+}
+
+func (s *systemd) Kind() string {
+	return "systemd"
+}
+
+// unitName derives the unit name from the launchd-style label,
+// e.g. "com.netmon.service" becomes "netmon-service.service".
+func unitName(spec Spec) string {
+	name := strings.TrimPrefix(spec.Label, "com.")
+	return strings.ReplaceAll(name, ".", "-") + ".service"
+}
+
+func (s *systemd) ConfigPath(spec Spec) string {
+	return filepath.Join(s.dir, unitName(spec))
+}
+
+func (s *systemd) Render(spec Spec) []byte {
+	cmdline := make([]string, 0, len(spec.Args)+1)
+	for _, arg := range append([]string{spec.Program}, spec.Args...) {
+		cmdline = append(cmdline, strconv.Quote(arg))
+	}
+
+	target := "default.target"
+	after := "network.target"
+	if spec.GUISession {
+		target = "graphical-session.target"
+		after = "graphical-session.target"
+	}
+
+	return []byte(fmt.Sprintf(`[Unit]
+Description=netmon (%s)
+After=%s
+
+[Service]
+ExecStart=%s
+Restart=always
+RestartSec=5
+StandardOutput=append:%s
+StandardError=append:%s
+Environment=PATH=/usr/local/bin:/usr/bin:/bin:/usr/sbin:/sbin
+
+[Install]
+WantedBy=%s
+`, spec.Label, after, strings.Join(cmdline, " "), spec.StdoutPath, spec.StderrPath, target))
+}
+
+func (s *systemd) Install(spec Spec) error {
+	if err := writeConfig(s.ConfigPath(spec), s.Render(spec)); err != nil {
+		return err
+	}
+	if err := systemctl("daemon-reload"); err != nil {
+		return err
+	}
+	if err := systemctl("enable", unitName(spec)); err != nil {
+		return err
+	}
+	return systemctl("restart", unitName(spec))
+}
+
+func (s *systemd) Status(spec Spec) (Status, error) {
+	status := Status{ConfigPath: s.ConfigPath(spec)}
+	status.Installed = fileExists(status.ConfigPath)
+	if !status.Installed {
+		return status, nil
+	}
+
+	output, err := exec.Command("systemctl", "--user", "show", "-p", "MainPID", "--value", unitName(spec)).Output()
+	if err != nil {
+		return status, fmt.Errorf("systemctl show %s: %w", unitName(spec), err)
+	}
+
+	status.PID, _ = strconv.Atoi(string(bytes.TrimSpace(output)))
+	status.Running = status.PID > 0
+	return status, nil
+}
+
+func (s *systemd) Start(spec Spec) error {
+	return systemctl("start", unitName(spec))
+}
+
+func (s *systemd) Stop(spec Spec) error {
+	return systemctl("stop", unitName(spec))
+}
+
+func (s *systemd) Restart(spec Spec) error {
+	return systemctl("restart", unitName(spec))
+}
+
+func (s *systemd) Uninstall(spec Spec) error {
+	path := s.ConfigPath(spec)
+	if !fileExists(path) {
+		return nil
+	}
+
+	// Ignore errors, the unit might not be running or enabled
+	exec.Command("systemctl", "--user", "disable", "--now", unitName(spec)).Run()
+
+	if err := os.Remove(path); err != nil {
+		return fmt.Errorf("remove %s: %w", path, err)
+	}
+	return systemctl("daemon-reload")
+}
+
+// systemctl runs a user-scoped systemctl command and includes its output in any error.
+func systemctl(args ...string) error {
+	args = append([]string{"--user"}, args...)
+	output, err := exec.Command("systemctl", args...).CombinedOutput()
+	if err != nil {
+		return fmt.Errorf("systemctl %s: %w\n%s", strings.Join(args, " "), err, bytes.TrimSpace(output))
+	}
+	return nil
+}
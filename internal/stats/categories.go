@@ -0,0 +1,126 @@
+package stats
+
+import (
+	"netmon/internal/db"
+	"netmon/internal/rules"
+)
+
+// UncategorizedLabel is the category of apps no rule assigns one to.
+const UncategorizedLabel = "Other"
+
+// CategorySummary represents traffic summary for a category of applications.
+type CategorySummary struct {
+	Category      string
+	Apps          int
+	TotalBytesIn  uint64
+	TotalBytesOut uint64
+}
+
+// ApplyRules regroups app summaries with the current rule set, so rules also
+// apply to data collected before they were written. apps supplies the
+// executable path recorded for each app key. Command line rules are skipped:
+// an app's command line is only recorded as last seen, so it cannot tell
+// which logs it applied to, and the service already matched them as the
+// traffic was collected. Categories come from the current rules only: an app
+// no rule assigns one to is uncategorized.
+func ApplyRules(summaries []AppSummary, apps []db.App, set *rules.Set) []AppSummary {
+	byKey := appsByKey(apps)
+	set = set.WithoutCmdline()
+
+	renamed := make([]AppSummary, 0, len(summaries))
+	for _, summary := range summaries {
+		renamed = append(renamed, renameByRules(summary, byKey, set))
+	}
+	return mergeSummaries(renamed)
+}
+
+// appsByKey indexes apps by their stable key.
+func appsByKey(apps []db.App) map[string]db.App {
+	byKey := make(map[string]db.App, len(apps))
+	for _, app := range apps {
+		byKey[app.Key] = app
+	}
+	return byKey
+}
+
+// renameByRules sets the key, name and category of a summary from a rule set
+// without command line rules (see ApplyRules).
+func renameByRules(summary AppSummary, byKey map[string]db.App, set *rules.Set) AppSummary {
+	app := byKey[summary.AppKey]
+	result := set.Match(rules.Subject{
+		Name:    summary.AppName,
+		ExePath: app.ExePath,
+	})
+
+	if result.App != "" {
+		summary.AppKey, summary.AppName = "rule:"+result.App, result.App
+	}
+
+	summary.Category = result.Category
+	if summary.Category == "" {
+		summary.Category = UncategorizedLabel
+	}
+
+	return summary
+}
+
+// AppCategory returns the category the current rules assign to an app, or
+// UncategorizedLabel.
+func AppCategory(app db.App, set *rules.Set) string {
+	summary := AppSummary{AppKey: app.Key, AppName: app.Name}
+	return renameByRules(summary, map[string]db.App{app.Key: app}, set.WithoutCmdline()).Category
+}
+
+// mergeSummaries combines summaries that share an app key, keeping first-seen order.
+func mergeSummaries(summaries []AppSummary) []AppSummary {
+	merged := make(map[string]*AppSummary)
+	order := make([]string, 0, len(summaries))
+
+	for _, summary := range summaries {
+		if existing, ok := merged[summary.AppKey]; ok {
+			existing.TotalBytesIn += summary.TotalBytesIn
+			existing.TotalBytesOut += summary.TotalBytesOut
+			continue
+		}
+
+		summary := summary
+		merged[summary.AppKey] = &summary
+		order = append(order, summary.AppKey)
+	}
+
+	result := make([]AppSummary, 0, len(order))
+	for _, key := range order {
+		result = append(result, *merged[key])
+	}
+	return result
+}
+
+// ComputeByCategory totals app summaries per category. Summaries should have
+// been passed through ApplyRules first.
+func ComputeByCategory(summaries []AppSummary) []CategorySummary {
+	byCategory := make(map[string]*CategorySummary)
+	order := make([]string, 0)
+
+	for _, summary := range summaries {
+		category := summary.Category
+		if category == "" {
+			category = UncategorizedLabel
+		}
+
+		cs, ok := byCategory[category]
+		if !ok {
+			cs = &CategorySummary{Category: category}
+			byCategory[category] = cs
+			order = append(order, category)
+		}
+		cs.Apps++
+		cs.TotalBytesIn += summary.TotalBytesIn
+		cs.TotalBytesOut += summary.TotalBytesOut
+	}
+
+	result := make([]CategorySummary, 0, len(order))
+	for _, category := range order {
+		result = append(result, *byCategory[category])
+	}
+	return result
+}
@@ -0,0 +1,134 @@
+package stats
+
+import (
+	"fmt"
+	"netmon/internal/db"
+	"sort"
+)
+
+// TimeRange is a span of time in Unix seconds, both ends inclusive.
+type TimeRange struct {
+	Start int64 `json:"start"`
+	End   int64 `json:"end"`
+}
+
+// Change is how the traffic of one interface, app or total differs between
+// two ranges.
+type Change struct {
+	Key      string   `json:"key"`
+	Label    string   `json:"label"`
+	Previous uint64   `json:"previous_bytes"`
+	Current  uint64   `json:"current_bytes"`
+	Delta    int64    `json:"delta_bytes"`
+	Percent  *float64 `json:"percent_change"` // nil if there was no traffic before
+}
+
+// Comparison is the traffic of a range compared with an earlier one.
+// Interfaces and apps are sorted by absolute change, largest first.
+type Comparison struct {
+	Previous   TimeRange `json:"previous"`
+	Current    TimeRange `json:"current"`
+	Total      Change    `json:"total"`
+	Interfaces []Change  `json:"interfaces"`
+	Apps       []Change  `json:"apps"`
+	NewApps    []Change  `json:"new_apps"`  // apps with traffic only in the current range
+	GoneApps   []Change  `json:"gone_apps"` // apps with traffic only in the previous range
+}
+
+// Compare compares the traffic of current with that of previous, in total,
+// per interface and per app. previous must end before current starts, so no
+// sample counts towards both.
+func Compare(database *db.DB, previous, current TimeRange) (Comparison, error) {
+	if previous.End >= current.Start {
+		return Comparison{}, fmt.Errorf("the previous range must end before the current one starts")
+	}
+	c := Comparison{Previous: previous, Current: current}
+
+	var err error
+	var totalPrevious, totalCurrent uint64
+	if c.Interfaces, totalPrevious, totalCurrent, err = compareBy(database, previous, current, ByInterface); err != nil {
+		return Comparison{}, err
+	}
+	c.Total = newChange("total", "Total", totalPrevious, totalCurrent)
+
+	if c.Apps, _, _, err = compareBy(database, previous, current, ByApp); err != nil {
+		return Comparison{}, err
+	}
+	for _, app := range c.Apps {
+		switch {
+		case app.Previous == 0 && app.Current > 0:
+			c.NewApps = append(c.NewApps, app)
+		case app.Previous > 0 && app.Current == 0:
+			c.GoneApps = append(c.GoneApps, app)
+		}
+	}
+
+	return c, nil
+}
+
+// compareBy compares two ranges grouped by one dimension, returning the
+// changes sorted by absolute change and the totals of both ranges.
+func compareBy(database *db.DB, previous, current TimeRange, dimension Dimension) ([]Change, uint64, uint64, error) {
+	before, err := Run(database, Query{Start: previous.Start, End: previous.End, GroupBy: []Dimension{dimension}})
+	if err != nil {
+		return nil, 0, 0, err
+	}
+	after, err := Run(database, Query{Start: current.Start, End: current.End, GroupBy: []Dimension{dimension}})
+	if err != nil {
+		return nil, 0, 0, err
+	}
+
+	labels := make(map[string]string)
+	bytesBefore := make(map[string]uint64)
+	bytesAfter := make(map[string]uint64)
+	var keys []string
+	var totalBefore, totalAfter uint64
+	for _, g := range before {
+		if _, ok := labels[g.Keys[0]]; !ok {
+			keys = append(keys, g.Keys[0])
+		}
+		labels[g.Keys[0]] = g.Labels[0]
+		bytesBefore[g.Keys[0]] += g.Total()
+		totalBefore += g.Total()
+	}
+	for _, g := range after {
+		if _, ok := labels[g.Keys[0]]; !ok {
+			keys = append(keys, g.Keys[0])
+		}
+		labels[g.Keys[0]] = g.Labels[0] // the newer name if an app was renamed
+		bytesAfter[g.Keys[0]] += g.Total()
+		totalAfter += g.Total()
+	}
+
+	changes := make([]Change, 0, len(keys))
+	for _, key := range keys {
+		changes = append(changes, newChange(key, labels[key], bytesBefore[key], bytesAfter[key]))
+	}
+	sort.Slice(changes, func(i, j int) bool {
+		return abs(changes[i].Delta) > abs(changes[j].Delta)
+	})
+
+	return changes, totalBefore, totalAfter, nil
+}
+
+func newChange(key, label string, previous, current uint64) Change {
+	c := Change{
+		Key:      key,
+		Label:    label,
+		Previous: previous,
+		Current:  current,
+		Delta:    int64(current) - int64(previous),
+	}
+	if previous > 0 {
+		percent := float64(c.Delta) * 100 / float64(previous)
+		c.Percent = &percent
+	}
+	return c
+}
+
+func abs(n int64) int64 {
+	if n < 0 {
+		return -n
+	}
+	return n
+}
@@ -0,0 +1,40 @@
+package stats
+
+import "netmon/internal/db"
+
+// Labels for traffic of processes outside any container or systemd unit.
+const (
+	HostLabel   = "(host)"
+	NoUnitLabel = "(none)"
+)
+
+// ComputeNetnsByContainer computes per-container totals from the interface
+// counters read inside container network namespaces; other namespaces are
+// skipped. Apps is always zero, as these counters are not attributed to
+// processes.
+func ComputeNetnsByContainer(namespaces []db.NetnsTotals) []GroupSummary {
+	summaries := make(map[string]*GroupSummary)
+	order := make([]string, 0)
+
+	for _, ns := range namespaces {
+		container := ns.ContainerID
+		if container == "" {
+			continue
+		}
+
+		summary, ok := summaries[container]
+		if !ok {
+			summary = &GroupSummary{Label: container}
+			summaries[container] = summary
+			order = append(order, container)
+		}
+		summary.TotalBytesIn += ns.BytesIn
+		summary.TotalBytesOut += ns.BytesOut
+	}
+
+	result := make([]GroupSummary, 0, len(order))
+	for _, container := range order {
+		result = append(result, *summaries[container])
+	}
+	return result
+}
@@ -0,0 +1,78 @@
+package stats
+
+import (
+	"netmon/internal/db"
+	"time"
+)
+
+// Gap is a window in which no samples were recorded, e.g. because the
+// machine was asleep or netmon-service was stopped.
+type Gap struct {
+	Start int64 // last sample before the outage (or range start)
+	End   int64 // first sample after the outage (or range end)
+}
+
+// Duration returns the length of the gap.
+func (g Gap) Duration() time.Duration {
+	return time.Duration(g.End-g.Start) * time.Second
+}
+
+// Coverage describes how much of a time range is backed by samples.
+type Coverage struct {
+	Start   int64
+	End     int64
+	Missing int64 // seconds without samples
+	Gaps    []Gap
+}
+
+// Percent returns the share of the range covered by samples, from 0 to 100.
+func (c Coverage) Percent() float64 {
+	span := c.End - c.Start
+	if span <= 0 {
+		return 100
+	}
+	return 100 * float64(span-c.Missing) / float64(span)
+}
+
+// ComputeCoverage detects gaps between the interface samples within [start,
+// end]; interval is the expected seconds between samples. Spacing of more
+// than twice the interval is treated as a gap. A range starting at the start
+// of all time is covered from its first sample rather than the epoch.
+func ComputeCoverage(database *db.DB, start, end, interval int64) (Coverage, error) {
+	if interval <= 0 {
+		interval = 1
+	}
+	tolerance := 2 * interval
+
+	samples, err := database.GetSampleGaps(start, end, tolerance)
+	if err != nil {
+		return Coverage{}, err
+	}
+	if start == db.GetStartOfAllTime() && samples.First != 0 {
+		start = samples.First
+	}
+
+	c := Coverage{Start: start, End: end}
+	if samples.First == 0 {
+		c.Missing = end - start
+		if c.Missing > 0 {
+			c.Gaps = append(c.Gaps, Gap{Start: start, End: end})
+		}
+		return c, nil
+	}
+
+	addGap := func(from, to int64) {
+		if to-from > tolerance {
+			c.Gaps = append(c.Gaps, Gap{Start: from, End: to})
+			c.Missing += to - from - interval
+		}
+	}
+
+	addGap(start, samples.First)
+	for _, gap := range samples.Gaps {
+		addGap(gap.After, gap.Before)
+	}
+	addGap(samples.Last, end)
+
+	return c, nil
+}
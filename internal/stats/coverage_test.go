@@ -0,0 +1,99 @@
+package stats
+
+import (
+	"netmon/internal/db"
+	"reflect"
+	"testing"
+)
+
+func TestComputeCoverage(t *testing.T) {
+	const base = 1760000000
+
+	tests := []struct {
+		name        string
+		samples     []int64 // seconds after base
+		start, end  int64   // seconds after base
+		interval    int64
+		allTime     bool // range starts at the start of all time
+		wantGaps    []Gap
+		wantMissing int64
+	}{
+		{
+			name:    "every second",
+			samples: []int64{0, 1, 2, 3, 4, 5, 6, 7, 8, 9, 10},
+			start:   0, end: 10, interval: 1,
+		},
+		{
+			name:    "spacing of twice the interval at the edges is no gap",
+			samples: []int64{2, 3, 4, 5, 6, 7, 8},
+			start:   0, end: 10, interval: 1,
+		},
+		{
+			name:    "spacing of more than twice the interval at the edges",
+			samples: []int64{3, 4, 5, 6, 7},
+			start:   0, end: 10, interval: 1,
+			wantGaps:    []Gap{{base, base + 3}, {base + 7, base + 10}},
+			wantMissing: 4,
+		},
+		{
+			name:    "gap between samples",
+			samples: []int64{0, 1, 2, 4, 5, 9, 10},
+			start:   0, end: 10, interval: 1,
+			wantGaps:    []Gap{{base + 5, base + 9}},
+			wantMissing: 3,
+		},
+		{
+			name:    "tolerance scales with the interval",
+			samples: []int64{0, 10, 30, 61, 70},
+			start:   0, end: 70, interval: 10,
+			wantGaps:    []Gap{{base + 30, base + 61}},
+			wantMissing: 21,
+		},
+		{
+			name:    "samples outside the range are ignored",
+			samples: []int64{0, 1, 5, 6, 7, 12, 13},
+			start:   5, end: 10, interval: 1,
+			wantGaps:    []Gap{{base + 7, base + 10}},
+			wantMissing: 2,
+		},
+		{
+			name:  "no samples",
+			start: 0, end: 10, interval: 1,
+			wantGaps:    []Gap{{base, base + 10}},
+			wantMissing: 10,
+		},
+		{
+			name:    "all time starts at the first sample",
+			samples: []int64{100, 101, 102},
+			end:     102, interval: 1, allTime: true,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			database := openTestDB(t)
+			for _, ts := range tt.samples {
+				insertTrafficLogs(t, database, db.TrafficLog{Timestamp: base + ts, Interface: "eth0", Interval: tt.interval})
+			}
+
+			start := base + tt.start
+			if tt.allTime {
+				start = db.GetStartOfAllTime()
+			}
+			c, err := ComputeCoverage(database, start, base+tt.end, tt.interval)
+			if err != nil {
+				t.Fatalf("ComputeCoverage: %v", err)
+			}
+
+			if !reflect.DeepEqual(c.Gaps, tt.wantGaps) {
+				t.Errorf("gaps = %v, want %v", c.Gaps, tt.wantGaps)
+			}
+			if c.Missing != tt.wantMissing {
+				t.Errorf("missing = %d, want %d", c.Missing, tt.wantMissing)
+			}
+			if tt.allTime && c.Start != base+tt.samples[0] {
+				t.Errorf("start = %d, want the first sample %d", c.Start, base+tt.samples[0])
+			}
+		})
+	}
+}
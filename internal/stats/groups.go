@@ -0,0 +1,31 @@
+package stats
+
+import "netmon/internal/db"
+
+// GroupSummary represents traffic summary for one value of a log attribute,
+// e.g. a container, protocol or destination scope.
+type GroupSummary struct {
+	Label         string
+	Apps          int
+	TotalBytesIn  uint64
+	TotalBytesOut uint64
+}
+
+// GroupSummaries converts per-value totals summed by the database, labelling
+// traffic without a value as fallback.
+func GroupSummaries(groups []db.GroupTotals, fallback string) []GroupSummary {
+	summaries := make([]GroupSummary, 0, len(groups))
+	for _, g := range groups {
+		label := g.Label
+		if label == "" {
+			label = fallback
+		}
+		summaries = append(summaries, GroupSummary{
+			Label:         label,
+			Apps:          g.Apps,
+			TotalBytesIn:  g.BytesIn,
+			TotalBytesOut: g.BytesOut,
+		})
+	}
+	return summaries
+}
@@ -0,0 +1,33 @@
+package stats
+
+import "netmon/internal/db"
+
+// NamespaceSummary represents traffic summary for one interface inside a
+// network namespace.
+type NamespaceSummary struct {
+	Netns         string
+	Name          string // "ip netns" name, if any
+	ContainerID   string
+	Process       string
+	Interface     string
+	TotalBytesIn  uint64
+	TotalBytesOut uint64
+}
+
+// ComputeByNamespace converts per-namespace, per-interface totals summed by
+// the database, which carry the labels of the most recent log of each.
+func ComputeByNamespace(namespaces []db.NetnsTotals) []NamespaceSummary {
+	result := make([]NamespaceSummary, 0, len(namespaces))
+	for _, ns := range namespaces {
+		result = append(result, NamespaceSummary{
+			Netns:         ns.Netns,
+			Name:          ns.Name,
+			ContainerID:   ns.ContainerID,
+			Process:       ns.Process,
+			Interface:     ns.Interface,
+			TotalBytesIn:  ns.BytesIn,
+			TotalBytesOut: ns.BytesOut,
+		})
+	}
+	return result
+}
@@ -0,0 +1,6 @@
+package stats
+
+import "netmon/internal/db"
+
+// UnclassifiedProtocol is the protocol of traffic recorded before protocols were tracked.
+const UnclassifiedProtocol = db.UnclassifiedProtocol
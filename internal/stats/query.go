@@ -0,0 +1,405 @@
+package stats
+
+import (
+	"fmt"
+	"math"
+	"netmon/internal/db"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// Dimension is something a query breaks traffic down by.
+type Dimension string
+
+// Dimensions a query can group by. Time dimensions use the local time zone.
+const (
+	ByInterface Dimension = "interface"
+	ByApp       Dimension = "app"
+	ByHour      Dimension = "hour"    // clock hour, e.g. "2026-10-18 14:00"
+	ByDay       Dimension = "day"     // calendar day, e.g. "2026-10-18"
+	ByWeekday   Dimension = "weekday" // day of the week, e.g. "Monday"
+)
+
+// Metric is a figure a query computes for each group.
+type Metric string
+
+// Metrics a query can compute. All but Sum need every sample of the range
+// rather than totals summed by the database.
+const (
+	Sum         Metric = "sum"         // bytes
+	PeakRate    Metric = "peak"        // highest per-second rate of one sample
+	AvgRate     Metric = "avg"         // bytes per second over the seconds covered by samples
+	Percentiles Metric = "percentiles" // per-second rates of samples at Query.Percentiles
+	ActiveTime  Metric = "active"      // seconds covered by samples with traffic
+)
+
+// Direction limits a query to downloaded or uploaded traffic.
+type Direction string
+
+// Directions of traffic.
+const (
+	BothDirections Direction = ""
+	Download       Direction = "in"
+	Upload         Direction = "out"
+)
+
+// DefaultPercentiles are computed for the Percentiles metric if a query
+// doesn't name any.
+var DefaultPercentiles = []float64{50, 95, 99}
+
+// quarterHour is the bucket totals are summed in when a query only needs
+// sums by time. Every time zone offset is a multiple of it, so a bucket
+// never straddles a local hour.
+const quarterHour = 15 * 60
+
+// Query describes a breakdown of the traffic of a range. App traffic is not
+// recorded per interface, so interfaces and apps can't be combined.
+type Query struct {
+	Start int64
+	End   int64
+
+	// Filters; empty fields match everything. User, Protocol and Scope
+	// apply to app traffic, see db.AppLogFilter.
+	Interface string
+	App       string // stable app key
+	User      string
+	Protocol  string
+	Scope     string
+	Direction Direction
+
+	GroupBy     []Dimension
+	Metrics     []Metric  // Sum if empty
+	Percentiles []float64 // for the Percentiles metric; DefaultPercentiles if empty
+}
+
+// Flow holds the metrics of one direction of traffic.
+type Flow struct {
+	Bytes       uint64
+	PeakRate    uint64
+	PeakAt      int64 // timestamp of the sample with PeakRate
+	AvgRate     uint64
+	Percentiles []uint64 // one per percentile of the query
+}
+
+// Group is the traffic of one combination of the query's dimensions.
+type Group struct {
+	Keys          []string // one per GroupBy dimension, sortable: app keys, dates, weekday numbers (Monday = 1)
+	Labels        []string // display names of the keys: app names, weekday names
+	In            Flow
+	Out           Flow
+	ActiveSeconds int64
+	Samples       int64 // samples or, if only sums were asked for, time buckets
+}
+
+// Total returns the bytes of both directions.
+func (g Group) Total() uint64 {
+	return g.In.Bytes + g.Out.Bytes
+}
+
+// Label joins the display names of the group's keys.
+func (g Group) Label() string {
+	return strings.Join(g.Labels, " / ")
+}
+
+// usesApps reports whether the query needs app traffic rather than interface traffic.
+func (q Query) usesApps() bool {
+	return q.App != "" || q.User != "" || q.Protocol != "" || q.Scope != "" || q.has(ByApp)
+}
+
+func (q Query) has(dimension Dimension) bool {
+	for _, d := range q.GroupBy {
+		if d == dimension {
+			return true
+		}
+	}
+	return false
+}
+
+func (q Query) wants(metric Metric) bool {
+	for _, m := range q.Metrics {
+		if m == metric {
+			return true
+		}
+	}
+	return false
+}
+
+// validate checks the query and fills in defaults.
+func (q *Query) validate() error {
+	if q.usesApps() && (q.Interface != "" || q.has(ByInterface)) {
+		return fmt.Errorf("app traffic is not recorded per interface")
+	}
+
+	seen := make(map[Dimension]bool)
+	for _, d := range q.GroupBy {
+		switch d {
+		case ByInterface, ByApp, ByHour, ByDay, ByWeekday:
+		default:
+			return fmt.Errorf("unknown dimension %q", d)
+		}
+		if seen[d] {
+			return fmt.Errorf("dimension %q given twice", d)
+		}
+		seen[d] = true
+	}
+
+	if len(q.Metrics) == 0 {
+		q.Metrics = []Metric{Sum}
+	}
+	for _, m := range q.Metrics {
+		switch m {
+		case Sum, PeakRate, AvgRate, Percentiles, ActiveTime:
+		default:
+			return fmt.Errorf("unknown metric %q", m)
+		}
+	}
+
+	if q.wants(Percentiles) && len(q.Percentiles) == 0 {
+		q.Percentiles = DefaultPercentiles
+	}
+	for _, p := range q.Percentiles {
+		if p <= 0 || p > 100 {
+			return fmt.Errorf("invalid percentile %g", p)
+		}
+	}
+
+	switch q.Direction {
+	case BothDirections, Download, Upload:
+	default:
+		return fmt.Errorf("unknown direction %q", q.Direction)
+	}
+	return nil
+}
+
+// perSample reports whether any metric needs every sample.
+func (q Query) perSample() bool {
+	for _, m := range q.Metrics {
+		if m != Sum {
+			return true
+		}
+	}
+	return false
+}
+
+// accumulator collects the samples of one group.
+type accumulator struct {
+	group             Group
+	seconds           int64
+	ratesIn, ratesOut []uint64 // kept for percentiles only
+}
+
+// Run computes the groups of a query, ordered by key. Sums are left to the
+// database; rate metrics are computed here from the samples of the range.
+func Run(database *db.DB, q Query) ([]Group, error) {
+	if err := q.validate(); err != nil {
+		return nil, err
+	}
+
+	sq := db.SampleQuery{
+		Start:       q.Start,
+		End:         q.End,
+		Apps:        q.usesApps(),
+		Interface:   q.Interface,
+		Filter:      db.AppLogFilter{AppKey: q.App, User: q.User, Protocol: q.Protocol, Scope: q.Scope},
+		ByInterface: q.has(ByInterface),
+		ByApp:       q.has(ByApp),
+	}
+	if !q.perSample() {
+		sq.Bucket = quarterHour
+		if !q.has(ByHour) && !q.has(ByDay) && !q.has(ByWeekday) {
+			// Any bucket will do; this one puts the range in at most two
+			sq.Bucket = q.End - q.Start + 1
+		}
+	}
+
+	groups := make(map[string]*accumulator)
+	err := database.ScanSampleTotals(sq, func(s db.SampleTotals) error {
+		keys, labels := q.keys(s)
+		id := strings.Join(keys, "\x00")
+		acc, ok := groups[id]
+		if !ok {
+			acc = &accumulator{group: Group{Keys: keys, Labels: labels}}
+			groups[id] = acc
+		}
+		acc.add(s, q)
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	result := make([]Group, 0, len(groups))
+	for _, acc := range groups {
+		result = append(result, acc.finish(q))
+	}
+	sort.Slice(result, func(i, j int) bool {
+		return strings.Join(result[i].Keys, "\x00") < strings.Join(result[j].Keys, "\x00")
+	})
+	return result, nil
+}
+
+// keys returns the group keys and labels of a sample.
+func (q Query) keys(s db.SampleTotals) ([]string, []string) {
+	keys := make([]string, len(q.GroupBy))
+	labels := make([]string, len(q.GroupBy))
+	local := time.Unix(s.Timestamp, 0)
+
+	for i, d := range q.GroupBy {
+		switch d {
+		case ByInterface:
+			keys[i], labels[i] = s.Interface, s.Interface
+		case ByApp:
+			keys[i], labels[i] = s.AppKey, s.AppName
+		case ByHour:
+			keys[i] = local.Format("2006-01-02 15:00")
+			labels[i] = keys[i]
+		case ByDay:
+			keys[i] = local.Format("2006-01-02")
+			labels[i] = keys[i]
+		case ByWeekday:
+			weekday := int(local.Weekday())
+			if weekday == 0 { // Sunday
+				weekday = 7
+			}
+			keys[i], labels[i] = strconv.Itoa(weekday), local.Weekday().String()
+		}
+	}
+	return keys, labels
+}
+
+// add counts one sample or bucket towards the group.
+func (acc *accumulator) add(s db.SampleTotals, q Query) {
+	if q.Direction != Upload {
+		acc.group.In.Bytes += s.BytesIn
+	}
+	if q.Direction != Download {
+		acc.group.Out.Bytes += s.BytesOut
+	}
+	acc.group.Samples++
+
+	if s.Interval == 0 {
+		return // a bucket
+	}
+
+	rateIn, rateOut := perSecond(s.BytesIn, s.Interval), perSecond(s.BytesOut, s.Interval)
+	if q.Direction == Upload {
+		rateIn = 0
+	}
+	if q.Direction == Download {
+		rateOut = 0
+	}
+
+	acc.seconds += s.Interval
+	if rateIn > acc.group.In.PeakRate {
+		acc.group.In.PeakRate, acc.group.In.PeakAt = rateIn, s.Timestamp
+	}
+	if rateOut > acc.group.Out.PeakRate {
+		acc.group.Out.PeakRate, acc.group.Out.PeakAt = rateOut, s.Timestamp
+	}
+	if rateIn+rateOut > 0 {
+		acc.group.ActiveSeconds += s.Interval
+	}
+	if q.wants(Percentiles) {
+		acc.ratesIn = append(acc.ratesIn, rateIn)
+		acc.ratesOut = append(acc.ratesOut, rateOut)
+	}
+}
+
+// finish computes the metrics that need all samples of the group.
+func (acc *accumulator) finish(q Query) Group {
+	g := acc.group
+	if acc.seconds > 0 {
+		g.In.AvgRate = g.In.Bytes / uint64(acc.seconds)
+		g.Out.AvgRate = g.Out.Bytes / uint64(acc.seconds)
+	}
+	if q.wants(Percentiles) {
+		g.In.Percentiles = percentiles(acc.ratesIn, q.Percentiles)
+		g.Out.Percentiles = percentiles(acc.ratesOut, q.Percentiles)
+	}
+	return g
+}
+
+// perSecond converts a byte count over an interval in seconds to a
+// per-second rate. Samples covering longer intervals (e.g. the first after a
+// suspend) are averaged over their interval.
+func perSecond(bytes uint64, interval int64) uint64 {
+	if interval <= 1 {
+		return bytes
+	}
+	return bytes / uint64(interval)
+}
+
+// percentiles returns the nearest-rank percentiles of rates.
+func percentiles(rates []uint64, ps []float64) []uint64 {
+	values := make([]uint64, len(ps))
+	if len(rates) == 0 {
+		return values
+	}
+
+	sort.Slice(rates, func(i, j int) bool { return rates[i] < rates[j] })
+	for i, p := range ps {
+		rank := int(math.Ceil(p / 100 * float64(len(rates))))
+		if rank < 1 {
+			rank = 1
+		}
+		values[i] = rates[rank-1]
+	}
+	return values
+}
+
+// ParseDimensions parses a comma-separated list of dimensions, e.g. "app,day".
+func ParseDimensions(s string) ([]Dimension, error) {
+	var dimensions []Dimension
+	for _, name := range splitList(s) {
+		d := Dimension(name)
+		switch d {
+		case ByInterface, ByApp, ByHour, ByDay, ByWeekday:
+		case "iface":
+			d = ByInterface
+		default:
+			return nil, fmt.Errorf("unknown dimension %q (want interface, app, hour, day or weekday)", name)
+		}
+		dimensions = append(dimensions, d)
+	}
+	return dimensions, nil
+}
+
+// ParseMetrics parses a comma-separated list of metrics. Percentiles may be
+// named directly, e.g. "sum,peak,p95", and are returned separately.
+func ParseMetrics(s string) ([]Metric, []float64, error) {
+	var metrics []Metric
+	var ps []float64
+	for _, name := range splitList(s) {
+		if strings.HasPrefix(name, "p") && len(name) > 1 {
+			if p, err := strconv.ParseFloat(name[1:], 64); err == nil {
+				if len(ps) == 0 {
+					metrics = append(metrics, Percentiles)
+				}
+				ps = append(ps, p)
+				continue
+			}
+		}
+
+		m := Metric(name)
+		switch m {
+		case Sum, PeakRate, AvgRate, Percentiles, ActiveTime:
+		default:
+			return nil, nil, fmt.Errorf("unknown metric %q (want sum, peak, avg, percentiles, pNN or active)", name)
+		}
+		metrics = append(metrics, m)
+	}
+	return metrics, ps, nil
+}
+
+// splitList splits a comma-separated list, dropping blanks.
+func splitList(s string) []string {
+	var items []string
+	for _, item := range strings.Split(s, ",") {
+		if item = strings.TrimSpace(strings.ToLower(item)); item != "" {
+			items = append(items, item)
+		}
+	}
+	return items
+}
@@ -0,0 +1,206 @@
+package stats
+
+import (
+	"netmon/internal/db"
+	"reflect"
+	"testing"
+	"time"
+)
+
+func TestRunPercentiles(t *testing.T) {
+	const start = 1760000000
+
+	tests := []struct {
+		name        string
+		rates       []uint64 // downloaded bytes per second of consecutive samples
+		interval    int64
+		percentiles []float64
+		want        []uint64
+	}{
+		{
+			name:        "nearest rank",
+			rates:       []uint64{40, 10, 30, 20},
+			interval:    1,
+			percentiles: []float64{1, 25, 26, 50, 75, 100},
+			want:        []uint64{10, 10, 20, 20, 30, 40},
+		},
+		{
+			name:        "hundred samples",
+			rates:       sequence(1, 100),
+			interval:    1,
+			percentiles: DefaultPercentiles,
+			want:        []uint64{50, 95, 99},
+		},
+		{
+			name:        "single sample",
+			rates:       []uint64{7},
+			interval:    1,
+			percentiles: []float64{0.1, 50, 100},
+			want:        []uint64{7, 7, 7},
+		},
+		{
+			name:        "samples over longer intervals are averaged per second",
+			rates:       []uint64{10, 20, 30},
+			interval:    10,
+			percentiles: []float64{50, 100},
+			want:        []uint64{20, 30},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			database := openTestDB(t)
+			for i, rate := range tt.rates {
+				insertTrafficLogs(t, database, db.TrafficLog{
+					Timestamp: start + int64(i)*tt.interval,
+					Interface: "eth0",
+					BytesIn:   rate * uint64(tt.interval),
+					Interval:  tt.interval,
+				})
+			}
+
+			groups, err := Run(database, Query{
+				Start:       start,
+				End:         start + int64(len(tt.rates))*tt.interval,
+				Metrics:     []Metric{Percentiles},
+				Percentiles: tt.percentiles,
+			})
+			if err != nil {
+				t.Fatalf("Run: %v", err)
+			}
+			if len(groups) != 1 {
+				t.Fatalf("got %d groups, want 1", len(groups))
+			}
+			if got := groups[0].In.Percentiles; !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("percentiles %v = %v, want %v", tt.percentiles, got, tt.want)
+			}
+		})
+	}
+}
+
+func TestRunRates(t *testing.T) {
+	const start = 1760000000
+	database := openTestDB(t)
+	insertTrafficLogs(t, database,
+		db.TrafficLog{Timestamp: start, Interface: "eth0", BytesIn: 100, BytesOut: 10, Interval: 1},
+		db.TrafficLog{Timestamp: start, Interface: "wlan0", BytesIn: 50, BytesOut: 5, Interval: 1},
+		db.TrafficLog{Timestamp: start + 1, Interface: "eth0", Interval: 1},
+		db.TrafficLog{Timestamp: start + 11, Interface: "eth0", BytesIn: 1000, BytesOut: 20, Interval: 10},
+	)
+
+	groups, err := Run(database, Query{
+		Start:   start,
+		End:     start + 11,
+		Metrics: []Metric{Sum, PeakRate, AvgRate, ActiveTime},
+	})
+	if err != nil {
+		t.Fatalf("Run: %v", err)
+	}
+	if len(groups) != 1 {
+		t.Fatalf("got %d groups, want 1", len(groups))
+	}
+
+	// The interfaces of a sample count together; the last sample covers ten seconds
+	want := Group{
+		Keys:          []string{},
+		Labels:        []string{},
+		In:            Flow{Bytes: 1150, PeakRate: 150, PeakAt: start, AvgRate: 95},
+		Out:           Flow{Bytes: 35, PeakRate: 15, PeakAt: start, AvgRate: 2},
+		ActiveSeconds: 11,
+		Samples:       3,
+	}
+	if !reflect.DeepEqual(groups[0], want) {
+		t.Errorf("Run = %+v, want %+v", groups[0], want)
+	}
+}
+
+func TestRunTimeBoundaries(t *testing.T) {
+	// A Sunday night into Monday, in the local time zone the keys use
+	sunday := time.Date(2025, time.October, 12, 23, 0, 0, 0, time.Local).Unix()
+	samples := []int64{
+		sunday - 1,       // 22:59:59
+		sunday,           // 23:00:00
+		sunday + 3599,    // 23:59:59
+		sunday + 3600,    // Monday 00:00:00
+		sunday + 3600*25, // Tuesday 00:00:00
+	}
+
+	tests := []struct {
+		dimension Dimension
+		wantKeys  []string
+		wantBytes []uint64
+	}{
+		{ByHour, []string{"2025-10-12 22:00", "2025-10-12 23:00", "2025-10-13 00:00", "2025-10-14 00:00"}, []uint64{1, 6, 8, 16}},
+		{ByDay, []string{"2025-10-12", "2025-10-13", "2025-10-14"}, []uint64{7, 8, 16}},
+		{ByWeekday, []string{"1", "2", "7"}, []uint64{8, 16, 7}},
+	}
+
+	database := openTestDB(t)
+	for i, ts := range samples {
+		insertTrafficLogs(t, database, db.TrafficLog{Timestamp: ts, Interface: "eth0", BytesIn: 1 << i, Interval: 1})
+	}
+
+	// Sums alone are bucketed by the database; other metrics see every sample
+	for _, metric := range []Metric{Sum, PeakRate} {
+		for _, tt := range tests {
+			t.Run(string(tt.dimension)+"/"+string(metric), func(t *testing.T) {
+				groups, err := Run(database, Query{
+					Start:   samples[0],
+					End:     samples[len(samples)-1],
+					GroupBy: []Dimension{tt.dimension},
+					Metrics: []Metric{metric},
+				})
+				if err != nil {
+					t.Fatalf("Run: %v", err)
+				}
+
+				var keys []string
+				var bytes []uint64
+				for _, g := range groups {
+					keys = append(keys, g.Keys[0])
+					bytes = append(bytes, g.In.Bytes)
+				}
+				if !reflect.DeepEqual(keys, tt.wantKeys) || !reflect.DeepEqual(bytes, tt.wantBytes) {
+					t.Errorf("groups = %v with %v bytes, want %v with %v", keys, bytes, tt.wantKeys, tt.wantBytes)
+				}
+			})
+		}
+	}
+}
+
+func TestParseDimensions(t *testing.T) {
+	tests := []struct {
+		input   string
+		want    []Dimension
+		wantErr bool
+	}{
+		{input: "", want: nil},
+		{input: "app", want: []Dimension{ByApp}},
+		{input: "app,day", want: []Dimension{ByApp, ByDay}},
+		{input: " Hour , weekday ,", want: []Dimension{ByHour, ByWeekday}},
+		{input: "iface", want: []Dimension{ByInterface}},
+		{input: "interface,hour", want: []Dimension{ByInterface, ByHour}},
+		{input: "month", wantErr: true},
+		{input: "app,minute", wantErr: true},
+	}
+
+	for _, tt := range tests {
+		got, err := ParseDimensions(tt.input)
+		if (err != nil) != tt.wantErr {
+			t.Errorf("ParseDimensions(%q) error = %v, want error %v", tt.input, err, tt.wantErr)
+			continue
+		}
+		if !reflect.DeepEqual(got, tt.want) {
+			t.Errorf("ParseDimensions(%q) = %v, want %v", tt.input, got, tt.want)
+		}
+	}
+}
+
+// sequence returns the numbers from first to last.
+func sequence(first, last uint64) []uint64 {
+	var numbers []uint64
+	for n := first; n <= last; n++ {
+		numbers = append(numbers, n)
+	}
+	return numbers
+}
@@ -0,0 +1,53 @@
+package stats
+
+import "netmon/internal/db"
+
+// Reconciliation compares the traffic seen on interfaces with the traffic
+// attributed to apps over the same range.
+type Reconciliation struct {
+	Interface        Summary // interface counters
+	Attributed       Summary // traffic attributed to real apps
+	PermissionDenied Summary // traffic of processes netmon may not inspect
+	Unattributed     Summary // traffic seen while no app had an active connection
+}
+
+// Reconcile splits the per-app traffic of a range into attributed, permission
+// denied and unattributed traffic and sets it against the interface traffic.
+// apps are the groups of a query grouped by app.
+func Reconcile(interfaces Summary, apps []Group) Reconciliation {
+	r := Reconciliation{Interface: interfaces}
+
+	for _, app := range apps {
+		target := &r.Attributed
+		switch app.Keys[0] {
+		case db.PermissionDeniedAppKey:
+			target = &r.PermissionDenied
+		case db.UnattributedAppKey:
+			target = &r.Unattributed
+		}
+
+		target.TotalBytesIn += app.In.Bytes
+		target.TotalBytesOut += app.Out.Bytes
+	}
+
+	return r
+}
+
+// Missing returns the interface bytes (downloaded, uploaded) not found in app
+// stats at all. App and interface logs share one read of the counters each
+// tick, so anything missing means app collection failed for some samples.
+// Values are negative if app stats hold more than the interfaces saw.
+func (r Reconciliation) Missing() (int64, int64) {
+	recordedIn := r.Attributed.TotalBytesIn + r.PermissionDenied.TotalBytesIn + r.Unattributed.TotalBytesIn
+	recordedOut := r.Attributed.TotalBytesOut + r.PermissionDenied.TotalBytesOut + r.Unattributed.TotalBytesOut
+	return int64(r.Interface.TotalBytesIn) - int64(recordedIn), int64(r.Interface.TotalBytesOut) - int64(recordedOut)
+}
+
+// Share returns the percentage of the interface traffic that s makes up.
+func (r Reconciliation) Share(s Summary) float64 {
+	total := r.Interface.TotalBytesIn + r.Interface.TotalBytesOut
+	if total == 0 {
+		return 0
+	}
+	return float64(s.TotalBytesIn+s.TotalBytesOut) / float64(total) * 100
+}
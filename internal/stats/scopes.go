@@ -0,0 +1,5 @@
+package stats
+
+// UnknownScopeLabel is the scope or family of traffic without a remote
+// address, or recorded before destinations were tracked.
+const UnknownScopeLabel = "unknown"
@@ -1,9 +1,6 @@
 package stats
 
-import (
-	"fmt"
-	"netmon/internal/db"
-)
+import "fmt"
 
 // Summary represents aggregated network traffic statistics.
 type Summary struct {
@@ -13,24 +10,14 @@ type Summary struct {
 	PeakBytesOut  uint64 // bytes per second
 }
 
-// ComputeSummary calculates traffic summary from traffic logs.
-func ComputeSummary(logs []db.TrafficLog) Summary {
-	var s Summary
-
-	for _, log := range logs {
-		s.TotalBytesIn += log.BytesIn
-		s.TotalBytesOut += log.BytesOut
-
-		if log.BytesIn > s.PeakBytesIn {
-			s.PeakBytesIn = log.BytesIn
-		}
-
-		if log.BytesOut > s.PeakBytesOut {
-			s.PeakBytesOut = log.BytesOut
-		}
+// SummaryOf converts a query group to a summary.
+func SummaryOf(g Group) Summary {
+	return Summary{
+		TotalBytesIn:  g.In.Bytes,
+		TotalBytesOut: g.Out.Bytes,
+		PeakBytesIn:   g.In.PeakRate,
+		PeakBytesOut:  g.Out.PeakRate,
 	}
-
-	return s
 }
 
 // InterfaceSummary represents traffic summary for a single interface.
@@ -40,24 +27,16 @@ type InterfaceSummary struct {
 	TotalBytesOut uint64
 }
 
-// ComputeByInterface calculates traffic summary per interface.
-func ComputeByInterface(logsByInterface map[string][]db.TrafficLog) []InterfaceSummary {
-	summaries := make([]InterfaceSummary, 0, len(logsByInterface))
-
-	for iface, logs := range logsByInterface {
-		var totalIn, totalOut uint64
-		for _, log := range logs {
-			totalIn += log.BytesIn
-			totalOut += log.BytesOut
-		}
-
+// InterfaceSummaries converts the groups of a query grouped by interface.
+func InterfaceSummaries(groups []Group) []InterfaceSummary {
+	summaries := make([]InterfaceSummary, 0, len(groups))
+	for _, g := range groups {
 		summaries = append(summaries, InterfaceSummary{
-			Interface:     iface,
-			TotalBytesIn:  totalIn,
-			TotalBytesOut: totalOut,
+			Interface:     g.Keys[0],
+			TotalBytesIn:  g.In.Bytes,
+			TotalBytesOut: g.Out.Bytes,
 		})
 	}
-
 	return summaries
 }
 
@@ -91,29 +70,23 @@ func FormatBytesPerSec(bytesPerSec uint64) string {
 
 // AppSummary represents traffic summary for a single application.
 type AppSummary struct {
+	AppKey        string
 	AppName       string
+	Category      string // set by ApplyRules
 	TotalBytesIn  uint64
 	TotalBytesOut uint64
 }
 
-// ComputeByApp calculates traffic summary per application.
-func ComputeByApp(logsByApp map[string][]db.AppTrafficLog) []AppSummary {
-	summaries := make([]AppSummary, 0, len(logsByApp))
-
-	for app, logs := range logsByApp {
-		var totalIn, totalOut uint64
-		for _, log := range logs {
-			totalIn += log.BytesIn
-			totalOut += log.BytesOut
-		}
-
+// AppSummaries converts the groups of a query grouped by app.
+func AppSummaries(groups []Group) []AppSummary {
+	summaries := make([]AppSummary, 0, len(groups))
+	for _, g := range groups {
 		summaries = append(summaries, AppSummary{
-			AppName:       app,
-			TotalBytesIn:  totalIn,
-			TotalBytesOut: totalOut,
+			AppKey:        g.Keys[0],
+			AppName:       g.Labels[0],
+			TotalBytesIn:  g.In.Bytes,
+			TotalBytesOut: g.Out.Bytes,
 		})
 	}
-
 	return summaries
 }
-
@@ -0,0 +1,28 @@
+package stats
+
+import (
+	"netmon/internal/db"
+	"path/filepath"
+	"testing"
+)
+
+// openTestDB opens an empty database that is removed when the test ends.
+func openTestDB(t *testing.T) *db.DB {
+	t.Helper()
+	database, err := db.Open(filepath.Join(t.TempDir(), "netmon.db"))
+	if err != nil {
+		t.Fatalf("open database: %v", err)
+	}
+	t.Cleanup(func() { database.Close() })
+	return database
+}
+
+// insertTrafficLogs stores interface logs, failing the test on error.
+func insertTrafficLogs(t *testing.T, database *db.DB, logs ...db.TrafficLog) {
+	t.Helper()
+	for _, log := range logs {
+		if err := database.InsertTrafficLog(log); err != nil {
+			t.Fatalf("insert traffic log: %v", err)
+		}
+	}
+}
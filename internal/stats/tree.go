@@ -0,0 +1,99 @@
+package stats
+
+import (
+	"netmon/internal/db"
+	"netmon/internal/rules"
+)
+
+// AppTreeNode is an app together with the traffic of the child processes
+// rolled up to it by netmon-service.
+type AppTreeNode struct {
+	AppSummary              // totals including children
+	Children   []AppSummary // traffic of descendant apps, excluding the app's own
+}
+
+// ComputeAppTree groups app traffic, summed by the database per app and
+// owner, under the ancestor app that owns it. Traffic without an owner forms
+// top-level nodes of its own.
+func ComputeAppTree(totals []db.AppParentTotals) []AppTreeNode {
+	nodes := make(map[string]*AppTreeNode)
+	children := make(map[string]map[string]*AppSummary)
+	order := make([]string, 0, len(totals))
+
+	for _, t := range totals {
+		ownerKey, ownerName := t.AppKey, t.AppName
+		if t.ParentKey != "" {
+			ownerKey, ownerName = t.ParentKey, t.ParentName
+		}
+
+		node, ok := nodes[ownerKey]
+		if !ok {
+			node = &AppTreeNode{AppSummary: AppSummary{AppKey: ownerKey, AppName: ownerName}}
+			nodes[ownerKey] = node
+			children[ownerKey] = make(map[string]*AppSummary)
+			order = append(order, ownerKey)
+		}
+		node.TotalBytesIn += t.BytesIn
+		node.TotalBytesOut += t.BytesOut
+
+		if t.ParentKey == "" {
+			continue
+		}
+
+		child, ok := children[ownerKey][t.AppKey]
+		if !ok {
+			child = &AppSummary{AppKey: t.AppKey, AppName: t.AppName}
+			children[ownerKey][t.AppKey] = child
+		}
+		child.TotalBytesIn += t.BytesIn
+		child.TotalBytesOut += t.BytesOut
+	}
+
+	result := make([]AppTreeNode, 0, len(order))
+	for _, ownerKey := range order {
+		node := nodes[ownerKey]
+		for _, child := range children[ownerKey] {
+			node.Children = append(node.Children, *child)
+		}
+		result = append(result, *node)
+	}
+	return result
+}
+
+// ApplyRulesToTree applies the rule set to owners and children alike,
+// merging nodes and children that end up with the same app key. As with
+// ApplyRules, command line rules are skipped.
+func ApplyRulesToTree(nodes []AppTreeNode, apps []db.App, set *rules.Set) []AppTreeNode {
+	byKey := appsByKey(apps)
+	set = set.WithoutCmdline()
+
+	merged := make(map[string]*AppTreeNode)
+	order := make([]string, 0, len(nodes))
+
+	for _, node := range nodes {
+		owner := renameByRules(node.AppSummary, byKey, set)
+
+		renamedChildren := make([]AppSummary, 0, len(node.Children))
+		for _, child := range node.Children {
+			renamedChildren = append(renamedChildren, renameByRules(child, byKey, set))
+		}
+
+		existing, ok := merged[owner.AppKey]
+		if !ok {
+			merged[owner.AppKey] = &AppTreeNode{AppSummary: owner, Children: renamedChildren}
+			order = append(order, owner.AppKey)
+			continue
+		}
+		existing.TotalBytesIn += owner.TotalBytesIn
+		existing.TotalBytesOut += owner.TotalBytesOut
+		existing.Children = append(existing.Children, renamedChildren...)
+	}
+
+	result := make([]AppTreeNode, 0, len(order))
+	for _, key := range order {
+		node := merged[key]
+		node.Children = mergeSummaries(node.Children)
+		result = append(result, *node)
+	}
+	return result
+}
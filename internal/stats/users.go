@@ -0,0 +1,31 @@
+package stats
+
+import "netmon/internal/db"
+
+// UnknownUserLabel is shown for traffic whose process owner could not be read.
+const UnknownUserLabel = db.UnknownUser
+
+// UserSummary represents traffic summary for one user account.
+type UserSummary struct {
+	User          string
+	UID           int32 // -1 if unknown
+	Apps          int
+	TotalBytesIn  uint64
+	TotalBytesOut uint64
+}
+
+// ComputeByUser converts per-user totals summed by the database. Users are
+// labelled by username, else numeric UID, else UnknownUserLabel.
+func ComputeByUser(groups []db.GroupTotals) []UserSummary {
+	summaries := make([]UserSummary, 0, len(groups))
+	for _, g := range groups {
+		summaries = append(summaries, UserSummary{
+			User:          g.Label,
+			UID:           g.UID,
+			Apps:          g.Apps,
+			TotalBytesIn:  g.BytesIn,
+			TotalBytesOut: g.BytesOut,
+		})
+	}
+	return summaries
+}